@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/logger"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit"
+)
+
+var cmsCert string
+var cmsChain string
+var cmsAttached bool
+var cmsPEM bool
+var cmsEnvelope string
+var cmsContent string
+var cmsTrustBundle string
+
+func init() {
+	// bind to transit command
+	transitCmd.AddCommand(cmsCmd)
+	cmsCmd.AddCommand(cmsSignCmd)
+	cmsCmd.AddCommand(cmsVerifyCmd)
+
+	// add flags to sub command
+	cmsSignCmd.Flags().StringVarP(&signFile, "file", "f", "", "File to sign, or '-' for stdin")
+	cmsSignCmd.Flags().StringVarP(&cmsCert, "cert", "", "", "The PEM encoded signer certificate of the transit key")
+	cmsSignCmd.Flags().StringVarP(&cmsChain, "chain", "", "", "PEM encoded intermediate certificates to include, issuer of the signer certificate first")
+	cmsSignCmd.Flags().StringVarP(&transitKey, "transit-key", "t", "", "The name of the transit key")
+	cmsSignCmd.Flags().StringVarP(&transitMount, "mount", "", "transit", "Mount path of transit backend")
+	cmsSignCmd.Flags().IntVarP(&keyVersion, "version", "", 0, "Version of the transit key, or 0 for latest (default 0)")
+	cmsSignCmd.Flags().StringVarP(&signHashAlg, "hash", "", "sha2-256", "Digest algorithm: sha2-256, sha2-384 or sha2-512")
+	cmsSignCmd.Flags().BoolVarP(&cmsAttached, "attached", "", false, "Include the content in the SignedData (default detached)")
+	cmsSignCmd.Flags().BoolVarP(&cmsPEM, "pem", "", false, "PEM encoded output (default DER)")
+	cmsSignCmd.Flags().StringVarP(&certOut, "out", "o", "", "Output file for the CMS SignedData (default stdout)")
+
+	cmsVerifyCmd.Flags().StringVarP(&cmsEnvelope, "in", "i", "", "The PEM or DER encoded CMS SignedData")
+	cmsVerifyCmd.Flags().StringVarP(&cmsContent, "content", "c", "", "The signed content of a detached SignedData")
+	cmsVerifyCmd.Flags().StringVarP(&cmsTrustBundle, "trust-bundle", "", "", "PEM encoded trusted CA certificates")
+	cmsVerifyCmd.Flags().StringVarP(&certOut, "out", "o", "", "Output file for the content of an attached SignedData")
+
+	// required flags
+	//nolint
+	cmsSignCmd.MarkFlagRequired("transit-key")
+	//nolint
+	cmsSignCmd.MarkFlagRequired("file")
+	//nolint
+	cmsSignCmd.MarkFlagRequired("cert")
+	//nolint
+	cmsVerifyCmd.MarkFlagRequired("in")
+	//nolint
+	cmsVerifyCmd.MarkFlagRequired("trust-bundle")
+
+}
+
+var cmsCmd = &cobra.Command{
+	Use:   "cms",
+	Short: "Commands for CMS (PKCS#7) signatures with transit keys",
+	Run: func(cmd *cobra.Command, args []string) {
+
+		// command does nothing
+		err := cmd.Help()
+		if err != nil {
+			log.Fatal(err)
+		}
+		os.Exit(1)
+	},
+}
+
+var cmsSignCmd = &cobra.Command{
+	Use:   "sign",
+	Short: "Create a CMS SignedData with private key in transit backend",
+	Long:  "Create a CMS (PKCS#7) SignedData of a file, with signed attributes and the signer certificate, signed with private key in transit backend",
+	Run:   cmsSignRun,
+
+	Example: `
+   hc-vault-util transit cms sign --transit-key "exchange" --cert exchange.pem --chain intermediate.pem --file invoice.xml --out invoice.xml.p7s
+
+   # verify with openssl
+   openssl cms -verify -binary -inform DER -in invoice.xml.p7s -content invoice.xml -CAfile root.pem -out /dev/null
+
+Mandatory Environment Variables:
+- VAULT_ADDR: Address of the vault server
+- VAULT_TOKEN: Vault authentication token. With permission to read 'transit/keys/[KEY-NAME]' and write 'transit/sign/[KEY-NAME]'.
+
+Optional Environment Variables:
+- VAULT_CACERT: Path to a PEM encoded CA file to verify TLS on the VAULT_ADDR.
+- VAULT_CAPATH: Path to a directory of PEM encoded CA files to verify TLS on the VAULT_ADDR.
+- VAULT_SKIP_VERIFY: To disable TLS verification completely.
+
+Note: ed25519 keys always use a sha2-512 digest (RFC 8419), verifying them with openssl requires OpenSSL 3.2+.
+`,
+}
+
+var cmsVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify a CMS SignedData against a trust bundle",
+	Long:  "Verify the signatures of a CMS (PKCS#7) SignedData, and that the signer certificates chain to a trusted CA certificate. Does not require Vault.",
+	Run:   cmsVerifyRun,
+
+	Example: `
+   hc-vault-util transit cms verify --in invoice.xml.p7s --content invoice.xml --trust-bundle root.pem
+
+   # attached SignedData, extract the content
+   hc-vault-util transit cms verify --in invoice.p7m --trust-bundle root.pem --out invoice.xml
+`,
+}
+
+// cmsSignRun cobra server handler
+func cmsSignRun(cmd *cobra.Command, args []string) {
+
+	logger := logger.GenLogger(Debug, noColor)
+
+	transitClient, err := transit.NewTransitClient(logger)
+	if err != nil {
+		logger.Error("Error creating transit client", "error", err)
+		os.Exit(1)
+	}
+
+	// set key properties
+	transitClient.SetKeyProperties(transitMount, transitKey)
+
+	opts := transit.CMSOptions{
+		ChainFile: cmsChain,
+		HashAlg:   signHashAlg,
+		Detached:  !cmsAttached,
+		PEM:       cmsPEM,
+	}
+
+	err = transitClient.CMSSign(signFile, cmsCert, keyVersion, opts, certOut)
+	if err != nil {
+		logger.Error("Error creating CMS SignedData", "error", err)
+		os.Exit(1)
+	}
+
+}
+
+// cmsVerifyRun cobra server handler
+func cmsVerifyRun(cmd *cobra.Command, args []string) {
+
+	logger := logger.GenLogger(Debug, noColor)
+
+	transitClient := transit.NewOfflineTransitClient(logger)
+
+	err := transitClient.CMSVerify(cmsEnvelope, cmsContent, cmsTrustBundle, certOut)
+	if err != nil {
+		logger.Error("Error verifying CMS SignedData", "error", err)
+		os.Exit(1)
+	}
+
+}
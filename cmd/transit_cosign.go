@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/logger"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit"
+)
+
+var cosignSignatureOut string
+var cosignBundleOut string
+var cosignCert string
+
+func init() {
+	// bind to transit command
+	transitCmd.AddCommand(cosignCmd)
+	cosignCmd.AddCommand(cosignSignBlobCmd)
+
+	// add flags to sub command
+	cosignSignBlobCmd.Flags().StringVarP(&transitKey, "transit-key", "t", "", "The name of the transit key")
+	cosignSignBlobCmd.Flags().StringVarP(&transitMount, "mount", "", "transit", "Mount path of transit backend")
+	cosignSignBlobCmd.Flags().IntVarP(&keyVersion, "version", "", 0, "Version of the transit key, or 0 for latest (default 0)")
+	cosignSignBlobCmd.Flags().StringVarP(&cosignSignatureOut, "output-signature", "", "", "Output file for the base64 signature (default stdout)")
+	cosignSignBlobCmd.Flags().StringVarP(&cosignBundleOut, "bundle", "", "", "Output file for the signature bundle")
+	cosignSignBlobCmd.Flags().StringVarP(&cosignCert, "certificate", "", "", "PEM certificate of the transit key to include in the bundle")
+
+	// required flags
+	//nolint
+	cosignSignBlobCmd.MarkFlagRequired("transit-key")
+
+}
+
+var cosignCmd = &cobra.Command{
+	Use:   "cosign",
+	Short: "Commands for cosign compatible signatures with transit keys",
+	Run: func(cmd *cobra.Command, args []string) {
+
+		// command does nothing
+		err := cmd.Help()
+		if err != nil {
+			log.Fatal(err)
+		}
+		os.Exit(1)
+	},
+}
+
+var cosignSignBlobCmd = &cobra.Command{
+	Use:   "sign-blob BLOB",
+	Short: "Sign a blob as 'cosign sign-blob' with private key in transit backend",
+	Long:  "Sign a blob (or stdin with '-') with private key in transit backend, and output a signature and bundle that verify with 'cosign verify-blob' and the transit public key. Signatures are not uploaded to the Rekor transparency log.",
+	Args:  cobra.ExactArgs(1),
+	Run:   cosignSignBlobRun,
+
+	Example: `
+   hc-vault-util transit cosign sign-blob --transit-key "release" --output-signature app.tar.gz.sig --bundle app.tar.gz.bundle app.tar.gz
+
+   # verify with cosign
+   hc-vault-util transit pubkey --transit-key "release" --out release.pub
+   cosign verify-blob --key release.pub --signature app.tar.gz.sig --insecure-ignore-tlog app.tar.gz
+   cosign verify-blob --key release.pub --bundle app.tar.gz.bundle --insecure-ignore-tlog app.tar.gz
+
+Mandatory Environment Variables:
+- VAULT_ADDR: Address of the vault server
+- VAULT_TOKEN: Vault authentication token. With permission to read 'transit/keys/[KEY-NAME]' and write 'transit/sign/[KEY-NAME]'.
+
+Optional Environment Variables:
+- VAULT_CACERT: Path to a PEM encoded CA file to verify TLS on the VAULT_ADDR.
+- VAULT_CAPATH: Path to a directory of PEM encoded CA files to verify TLS on the VAULT_ADDR.
+- VAULT_SKIP_VERIFY: To disable TLS verification completely.
+
+Note: ed25519 keys sign the whole blob, which is sent to transit.
+`,
+}
+
+// cosignSignBlobRun cobra server handler
+func cosignSignBlobRun(cmd *cobra.Command, args []string) {
+
+	logger := logger.GenLogger(Debug, noColor)
+
+	transitClient, err := transit.NewTransitClient(logger)
+	if err != nil {
+		logger.Error("Error creating transit client", "error", err)
+		os.Exit(1)
+	}
+
+	// set key properties
+	transitClient.SetKeyProperties(transitMount, transitKey)
+
+	opts := transit.CosignOptions{
+		SignatureFile: cosignSignatureOut,
+		BundleFile:    cosignBundleOut,
+		CertFile:      cosignCert,
+	}
+
+	err = transitClient.CosignSignBlob(args[0], keyVersion, opts)
+	if err != nil {
+		logger.Error("Error signing blob", "error", err)
+		os.Exit(1)
+	}
+
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/logger"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit"
+)
+
+var revokedFile string
+var crlNumber string
+var crlNextUpdate string
+
+func init() {
+	// bind to transit command
+	transitCmd.AddCommand(crlCmd)
+	// add flags to sub command
+	crlCmd.Flags().StringVarP(&caCertFile, "ca-cert", "", "", "The PEM encoded CA certificate of the transit key")
+	crlCmd.Flags().StringVarP(&transitKey, "transit-key", "t", "", "The name of the transit key of the CA")
+	crlCmd.Flags().StringVarP(&transitMount, "mount", "", "transit", "Mount path of transit backend")
+	crlCmd.Flags().IntVarP(&keyVersion, "version", "", 0, "Version of the transit key, or 0 for latest (default 0)")
+	crlCmd.Flags().StringVarP(&revokedFile, "revoked", "", "", "YAML list of revoked certificates")
+	crlCmd.Flags().StringVarP(&crlNumber, "crl-number", "", "", "CRL number, must increase for each CRL (default current unix time)")
+	crlCmd.Flags().StringVarP(&crlNextUpdate, "next-update", "", "168h", "Validity of the CRL")
+	crlCmd.Flags().StringVarP(&certOut, "out", "o", "", "Output file for the PEM encoded CRL (default stdout)")
+
+	// required flags
+	//nolint
+	crlCmd.MarkFlagRequired("transit-key")
+	//nolint
+	crlCmd.MarkFlagRequired("ca-cert")
+	//nolint
+	crlCmd.MarkFlagRequired("revoked")
+
+}
+
+var crlCmd = &cobra.Command{
+	Use:   "crl",
+	Short: "Generate a CRL signed with a CA private key in transit backend",
+	Long:  "Generate an X.509 CRL from a list of revoked certificates, signed by a CA certificate whose private key is in transit backend",
+	Run:   crlRun,
+
+	Example: `
+   hc-vault-util transit crl --ca-cert ca.pem --transit-key "ca" --revoked revoked.yaml --next-update 24h --out ca.crl
+
+Mandatory Environment Variables:
+- VAULT_ADDR: Address of the vault server
+- VAULT_TOKEN: Vault authentication token. With permission to read 'transit/keys/[KEY-NAME]' and write 'transit/sign/[KEY-NAME]'.
+
+Optional Environment Variables:
+- VAULT_CACERT: Path to a PEM encoded CA file to verify TLS on the VAULT_ADDR.
+- VAULT_CAPATH: Path to a directory of PEM encoded CA files to verify TLS on the VAULT_ADDR.
+- VAULT_SKIP_VERIFY: To disable TLS verification completely.
+
+Revoked certificates YAML format:
+revoked:
+  - serial: "0x1f3a9c"            # decimal, 0x prefixed hex, or ':' separated hex
+    revoked_at: 2024-01-31T12:00:00Z
+    reason: keyCompromise          # optional
+  - serial: "51467576285894973203714063912942164697540653434"
+    revoked_at: 2024-02-01T08:30:00Z
+issued:                           # optional, valid certificates for 'transit ocsp'
+  - "0x2b4d1e"
+
+Revocation reasons: unspecified, keyCompromise, cACompromise, affiliationChanged, superseded, 
+cessationOfOperation, certificateHold, removeFromCRL, privilegeWithdrawn, aACompromise
+`,
+}
+
+// crlRun cobra server handler
+func crlRun(cmd *cobra.Command, args []string) {
+
+	logger := logger.GenLogger(Debug, noColor)
+
+	validity, err := time.ParseDuration(crlNextUpdate)
+	if err != nil || validity <= 0 {
+		logger.Error("Invalid next update", "next_update", crlNextUpdate, "error", err)
+		os.Exit(1)
+	}
+
+	number := big.NewInt(time.Now().Unix())
+	if crlNumber != "" {
+		number, err = transit.ParseSerialNumber(crlNumber)
+		if err != nil {
+			logger.Error("Invalid CRL number", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	transitClient, err := transit.NewTransitClient(logger)
+	if err != nil {
+		logger.Error("Error creating transit client", "error", err)
+		os.Exit(1)
+	}
+
+	// set key properties
+	transitClient.SetKeyProperties(transitMount, transitKey)
+
+	opts := transit.CRLOptions{
+		Number:     number,
+		NextUpdate: validity,
+	}
+
+	err = transitClient.GenCRL(caCertFile, keyVersion, revokedFile, opts, certOut)
+	if err != nil {
+		logger.Error("Error generating CRL", "error", err)
+		os.Exit(1)
+	}
+
+}
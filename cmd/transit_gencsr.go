@@ -9,22 +9,37 @@ import (
 )
 
 var cfsslCSRFile string
+var csrSpecFile string
 var keyVersion int
+var csrSigAlg string
+var csrHashAlg string
+var csrFormat string
+var csrKV2Mount string
+var csrKV2Path string
+var csrForce bool
 
 func init() {
 	// bind to root command
 	transitCmd.AddCommand(genCSRCmd)
 	// add flags to sub command
 	genCSRCmd.Flags().StringVarP(&cfsslCSRFile, "csr-json", "c", "", "The path to a cfssl csr file")
+	genCSRCmd.Flags().StringVarP(&csrSpecFile, "csr-spec", "s", "", "The path to a YAML or JSON CSR spec file, instead of a cfssl csr file")
 	genCSRCmd.Flags().StringVarP(&transitKey, "transit-key", "t", "", "The name of the transit key to import")
 	genCSRCmd.Flags().StringVarP(&transitMount, "mount", "", "transit", "Mount path of transit backend")
 	genCSRCmd.Flags().IntVarP(&keyVersion, "version", "", 0, "Version of the transit key, or 0 for latest (default 0)")
+	genCSRCmd.Flags().StringVarP(&csrSigAlg, "signature-algorithm", "", "", "Signature algorithm for RSA keys: pss or pkcs1v15 (default pkcs1v15)")
+	genCSRCmd.Flags().StringVarP(&csrFormat, "format", "f", "pem", "Output format: pem, der or json (CSR with transit key and metadata)")
+	genCSRCmd.Flags().StringVarP(&certOut, "out", "o", "", "New output file for the CSR, created with mode 0600 (default stdout)")
+	genCSRCmd.Flags().BoolVarP(&csrForce, "force", "", false, "Replace the --out file if it exists")
+	genCSRCmd.Flags().StringVarP(&csrKV2Mount, "csr-kv2-mount", "", "secret", "Mount path of kv2 backend where to store the CSR")
+	genCSRCmd.Flags().StringVarP(&csrKV2Path, "csr-kv2-path", "", "", "Path of kv2 secret where to store the CSR and its metadata (disabled if empty)")
+	genCSRCmd.Flags().StringVarP(&csrHashAlg, "hash", "", "", "Hash algorithm: sha2-256, sha2-384 or sha2-512 (default sha2-384 for rsa-3072 and ecdsa-p384, sha2-512 for rsa-4096 and ecdsa-p521, else sha2-256)")
 
 	// required flags
 	//nolint
 	genCSRCmd.MarkFlagRequired("transit-key")
-	//nolint
-	genCSRCmd.MarkFlagRequired("csr-json")
+
+	genCSRCmd.MarkFlagsMutuallyExclusive("csr-json", "csr-spec")
 
 }
 
@@ -37,9 +52,22 @@ var genCSRCmd = &cobra.Command{
 	Example: `
    hc-vault-util transit gencsr --csr-json example/csr.json --transit-key "rsa" 
 
+   # RSA PSS with SHA-384
+   hc-vault-util transit gencsr --csr-json example/csr.json --transit-key "rsa" --signature-algorithm pss --hash sha2-384
+
+   # ECDSA with SHA-512
+   hc-vault-util transit gencsr --csr-json example/csr.json --transit-key "ecdsa" --hash sha2-512
+
+   # CSR spec with key usage, extended key usage and custom extensions
+   hc-vault-util transit gencsr --csr-spec example/csr.yaml --transit-key "rsa"
+
+   # write the CSR to a file, and store it with its metadata in kv2 'secret/csr/rsa'
+   hc-vault-util transit gencsr --csr-json example/csr.json --transit-key "rsa" --out rsa.csr --csr-kv2-path csr/rsa
+
 Mandatory Environment Variables:
 - VAULT_ADDR: Address of the vault server 
 - VAULT_TOKEN: Vault authentication token. With permission to read 'transit/keys/[KEY-NAME]' and write 'transit/sign/[KEY-NAME]'.
+  (and write '[CSR-KV2-MOUNT]/data/[CSR-KV2-PATH]' if --csr-kv2-path is set)
 
 Optional Environment Variables:
 - VAULT_CACERT: Path to a PEM encoded CA file to verify TLS on the VAULT_ADDR.
@@ -66,6 +94,30 @@ CSR JSON format:
 }
  
 
+CSR spec format (YAML or JSON):
+subject:
+  common_name: device-42.example.com
+  serial_number: "42"
+  country: [US]
+  organization: [Example]
+  organizational_unit: [Devices]
+  extra_names:
+    - oid: 2.5.4.12
+      value: Sensor
+dns_names: [device-42.example.com]
+ip_addresses: [10.0.0.42]
+email_addresses: [ops@example.com]
+uris: ["spiffe://example.com/device/42"]
+key_usage: [digital signature, key encipherment]
+ext_key_usage: [server auth, client auth, 1.3.6.1.5.5.7.3.17]
+basic_constraints:
+  is_ca: false
+extensions:
+  - oid: 1.2.3.4.5
+    critical: false
+    value: DAVoZWxsbw==  # base64 DER value
+challenge_password: s3cret  # optional PKCS#9 attribute, stored in clear in the CSR
+
 `,
 }
 
@@ -74,6 +126,11 @@ func genCSRRun(cmd *cobra.Command, args []string) {
 
 	logger := logger.GenLogger(Debug, noColor)
 
+	if cfsslCSRFile == "" && csrSpecFile == "" {
+		logger.Error("one of --csr-json or --csr-spec is required")
+		os.Exit(1)
+	}
+
 	transitClient, err := transit.NewTransitClient(logger)
 	if err != nil {
 		logger.Error("Error creating transit client", "error", err)
@@ -83,7 +140,31 @@ func genCSRRun(cmd *cobra.Command, args []string) {
 	// set key properties
 	transitClient.SetKeyProperties(transitMount, transitKey)
 
-	err = transitClient.GenCSR(cfsslCSRFile, keyVersion)
+	opts := transit.CSROptions{
+		SigAlg:   csrSigAlg,
+		HashAlg:  csrHashAlg,
+		Format:   csrFormat,
+		Force:    csrForce,
+		KV2Mount: csrKV2Mount,
+		KV2Path:  csrKV2Path,
+	}
+
+	if csrSpecFile != "" {
+		spec, err := transit.LoadCSRSpec(csrSpecFile)
+		if err != nil {
+			logger.Error("Error loading CSR spec", "error", err)
+			os.Exit(1)
+		}
+
+		err = transitClient.GenCSRFromSpec(spec, keyVersion, opts, certOut)
+		if err != nil {
+			logger.Error("Error generating CSR", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	err = transitClient.GenCSR(cfsslCSRFile, keyVersion, opts, certOut)
 	if err != nil {
 		logger.Error("Error generating CSR", "error", err)
 		os.Exit(1)
@@ -3,7 +3,9 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"text/tabwriter"
 
+	"github.com/hashicorp/go-hclog"
 	"github.com/spf13/cobra"
 	"github.com/vdbulcke/hc-vault-util/hc-vault-util/logger"
 	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit"
@@ -13,41 +15,149 @@ import (
 var privKey string
 var transitMount string
 var transitKey string
+var passphraseEnv string
+var passphraseFile string
+var pkcs12Bundle string
+var certKV2Mount string
+var certKV2Path string
+var importAsNewVersion bool
+var importHashFunction string
+var importExportable bool
+var importAllowPlaintextBackup bool
+var importAllowRotation bool
+var importAutoRotatePeriod string
+var importDerived bool
+var importContext string
+var symmetricKey string
+var symmetricKeyType string
+var symmetricKeyEncoding string
+var ciphertextFile string
+var importVerify bool
+var importManifest string
+
+// importManifestExclusiveFlags are the import flags of a single key, that
+// cannot be used with --manifest
+var importManifestExclusiveFlags = []string{
+	"pkcs8-pem-key", "pkcs12", "symmetric-key", "ciphertext-file", "key-type", "key-encoding",
+	"passphrase-env", "passphrase-file", "cert-kv2-mount", "cert-kv2-path",
+	"as-new-version", "verify", "hash-function", "exportable", "allow-plaintext-backup",
+	"allow-rotation", "auto-rotate-period", "derived", "context",
+}
 
 func init() {
 	// bind to root command
 	transitCmd.AddCommand(importCmd)
 	// add flags to sub command
-	importCmd.Flags().StringVarP(&privKey, "pkcs8-pem-key", "k", "", "The private key to import")
+	importCmd.Flags().StringVarP(&privKey, "pkcs8-pem-key", "k", "", "The private key to import (PEM encoded PKCS8, encrypted PKCS8, PKCS1 or SEC1)")
 	importCmd.Flags().StringVarP(&transitKey, "transit-key", "t", "", "The name of the transit key to import")
 	importCmd.Flags().StringVarP(&transitMount, "mount", "", "transit", "Mount path of transit backend")
+	importCmd.Flags().StringVarP(&passphraseEnv, "passphrase-env", "", "", "Name of the env variable holding the passphrase of an encrypted private key or PKCS12 bundle")
+	importCmd.Flags().StringVarP(&passphraseFile, "passphrase-file", "", "", "Path to a file holding the passphrase of an encrypted private key or PKCS12 bundle")
+	importCmd.Flags().StringVarP(&pkcs12Bundle, "pkcs12", "", "", "The PKCS12 (PFX) bundle holding the private key to import")
+	importCmd.Flags().StringVarP(&symmetricKey, "symmetric-key", "", "", "The symmetric or hmac key material to import")
+	importCmd.Flags().StringVarP(&symmetricKeyType, "key-type", "", "", "Transit type of the symmetric key (one of aes128-gcm96, aes256-gcm96, chacha20-poly1305, hmac) or of the wrapped key")
+	importCmd.Flags().StringVarP(&ciphertextFile, "ciphertext-file", "", "", "The base64 ciphertext of a key wrapped offline with 'transit wrap'")
+	importCmd.Flags().StringVarP(&symmetricKeyEncoding, "key-encoding", "", "raw", "Encoding of the symmetric key file, one of raw, hex, base64")
+	importCmd.Flags().StringVarP(&certKV2Mount, "cert-kv2-mount", "", "secret", "Mount path of kv2 backend where to store the PKCS12 certificate")
+	importCmd.Flags().StringVarP(&certKV2Path, "cert-kv2-path", "", "", "Path of kv2 secret where to store the PKCS12 certificate and chain (disabled if empty)")
+	importCmd.Flags().BoolVarP(&importAsNewVersion, "as-new-version", "", false, "Import as a new version of an existing transit key (key rotation)")
+	importCmd.Flags().StringVarP(&importManifest, "manifest", "", "", "YAML manifest of keys to import in bulk")
+	importCmd.Flags().BoolVarP(&importVerify, "verify", "", false, "Verify the imported private key: compare public keys and check a signature from transit. Runs AFTER the import: the key is NOT removed from transit if verification fails")
+	importCmd.Flags().StringVarP(&importHashFunction, "hash-function", "", "SHA256", "Hash function used for RSA-OAEP wrapping, one of SHA1, SHA224, SHA256, SHA384, SHA512")
+	importCmd.Flags().BoolVarP(&importExportable, "exportable", "", false, "Allow the imported key to be exported")
+	importCmd.Flags().BoolVarP(&importAllowPlaintextBackup, "allow-plaintext-backup", "", false, "Allow plaintext backup of the imported key (cannot be disabled later)")
+	importCmd.Flags().BoolVarP(&importAllowRotation, "allow-rotation", "", false, "Allow transit to rotate the imported key")
+	importCmd.Flags().StringVarP(&importAutoRotatePeriod, "auto-rotate-period", "", "", "Auto rotation period of the imported key (e.g. 720h, 30d or seconds), requires --allow-rotation")
+	importCmd.Flags().BoolVarP(&importDerived, "derived", "", false, "Enable key derivation for the imported key (ed25519 and symmetric keys only)")
+	importCmd.Flags().StringVarP(&importContext, "context", "", "", "Base64 encoded context for key derivation, requires --derived")
 
-	// required flags
-	//nolint
-	importCmd.MarkFlagRequired("pkcs8-pem-key")
-	//nolint
-	importCmd.MarkFlagRequired("transit-key")
+	importCmd.MarkFlagsMutuallyExclusive("passphrase-env", "passphrase-file")
+	importCmd.MarkFlagsMutuallyExclusive("pkcs8-pem-key", "pkcs12", "symmetric-key", "ciphertext-file", "manifest")
+	// transit key is set by manifest entries
+	importCmd.MarkFlagsMutuallyExclusive("transit-key", "manifest")
+	importCmd.MarkFlagsRequiredTogether("symmetric-key", "key-type")
+	importCmd.MarkFlagsRequiredTogether("ciphertext-file", "key-type")
+	// verify requires the local private key
+	importCmd.MarkFlagsMutuallyExclusive("verify", "symmetric-key")
+	importCmd.MarkFlagsMutuallyExclusive("verify", "ciphertext-file")
 
 }
 
 var importCmd = &cobra.Command{
 	Use:   "import",
 	Short: "Imports private key into transit backend",
-	Long:  "Imports private PKCS8 PEM private key into transit backend",
+	Long:  "Imports private PEM private key (PKCS8, encrypted PKCS8, PKCS1 or SEC1), PKCS12 bundle, symmetric key or offline wrapped key into transit backend",
 	Run:   importRun,
 
 	Example: `
    hc-vault-util transit import --pkcs8-pem-key ./key.pem --transit-key rsa-key 
 
+   # encrypted key, passphrase from env variable (or --passphrase-file, or interactive prompt by default)
+   hc-vault-util transit import --pkcs8-pem-key ./key.enc.pem --transit-key rsa-key --passphrase-env KEY_PASSPHRASE
+
+   # PKCS12 bundle, password from file, and store certificate and chain in kv2 'secret/certs/rsa-key'
+   hc-vault-util transit import --pkcs12 ./bundle.p12 --passphrase-file ./p12.pass --transit-key rsa-key --cert-kv2-path certs/rsa-key
+
+   # symmetric key, hex encoded
+   hc-vault-util transit import --symmetric-key ./aes.hex --key-encoding hex --key-type aes256-gcm96 --transit-key aes-key
+
+   # key wrapped offline with 'transit wrap'
+   hc-vault-util transit import --ciphertext-file ./key.wrapped --key-type rsa-2048 --transit-key rsa-key
+
+   # rotate an existing imported key with a new version
+   hc-vault-util transit import --pkcs8-pem-key ./new-key.pem --transit-key rsa-key --as-new-version
+
+   # verify the imported key with a transit signature
+   # WARNING: verification runs after the import, a key failing verification remains in transit
+   hc-vault-util transit import --pkcs8-pem-key ./key.pem --transit-key rsa-key --verify
+
+   # bulk import from manifest, only --mount (default mount of the entries) can be set with --manifest
+   hc-vault-util transit import --manifest ./keys.yaml
+
+   # key policy
+   hc-vault-util transit import --pkcs8-pem-key ./key.pem --transit-key rsa-key --exportable --allow-rotation --auto-rotate-period 720h
+
 Mandatory Environment Variables:
 - VAULT_ADDR: Address of the vault server 
 - VAULT_TOKEN: Vault authentication token. With permission to read transit/wrapping_key and write transit/keys/[KEY-NAME]/import.
+  (with --as-new-version: read transit/keys/[KEY-NAME] and write transit/keys/[KEY-NAME]/import_version instead)
+  (with --verify: read transit/keys/[KEY-NAME] and write transit/sign/[KEY-NAME])
+  (and write '[CERT-KV2-MOUNT]/data/[CERT-KV2-PATH]' if --cert-kv2-path is set)
 
 Optional Environment Variables:
 - VAULT_CACERT: Path to a PEM encoded CA file to verify TLS on the VAULT_ADDR.
 - VAULT_CAPATH: Path to a directory of PEM encoded CA files to verify TLS on the VAULT_ADDR.
 - VAULT_SKIP_VERIFY: To disable TLS verification completely.
 
+Manifest YAML format (relative file paths are relative to the manifest directory): 
+keys:
+    # PEM private key with key policy
+  - name: rsa-key
+    mount: transit  # optional, default --mount
+    key_file: ./rsa.pem
+    passphrase_env: RSA_KEY_PASSPHRASE
+    exportable: true
+    verify: true  # key_file and pkcs12 only, runs after the import
+    # PKCS12 bundle
+  - name: ec-key
+    pkcs12: ./ec.p12
+    passphrase_file: ./ec.pass
+    cert_kv2_path: certs/ec-key
+    # symmetric key
+  - name: aes-key
+    symmetric_key: ./aes.hex
+    key_encoding: hex
+    key_type: aes256-gcm96
+    derived: true
+    # new version of existing key
+  - name: rsa-key-2
+    key_file: ./rsa2.pem
+    as_new_version: true
+
+Available options per key: key_file, pkcs12, symmetric_key, ciphertext_file, key_type, key_encoding, 
+passphrase_env, passphrase_file, cert_kv2_mount, cert_kv2_path, as_new_version, verify, hash_function, 
+exportable, allow_plaintext_backup, allow_rotation, auto_rotate_period, derived, context
+
 Docs: 
 - https://developer.hashicorp.com/vault/docs/secrets/transit/key-wrapping-guide#software-example-go
 `,
@@ -58,16 +168,66 @@ func importRun(cmd *cobra.Command, args []string) {
 
 	logger := logger.GenLogger(Debug, noColor)
 
+	src := transit.ImportSource{
+		KeyFile:        privKey,
+		PKCS12:         pkcs12Bundle,
+		SymmetricKey:   symmetricKey,
+		CiphertextFile: ciphertextFile,
+		KeyType:        symmetricKeyType,
+		KeyEncoding:    symmetricKeyEncoding,
+		PassphraseEnv:  passphraseEnv,
+		PassphraseFile: passphraseFile,
+		CertKV2Mount:   certKV2Mount,
+		CertKV2Path:    certKV2Path,
+	}
+
+	if importManifest != "" {
+		// key sources and options are set per manifest entry
+		for _, name := range importManifestExclusiveFlags {
+			if cmd.Flags().Changed(name) {
+				logger.Error("flag cannot be used with --manifest, set it in the manifest entries", "flag", "--"+name)
+				os.Exit(1)
+			}
+		}
+	} else {
+		if transitKey == "" {
+			logger.Error("one of --transit-key or --manifest is required")
+			os.Exit(1)
+		}
+
+		err := src.Validate()
+		if err != nil {
+			logger.Error("Invalid key source", "error", err)
+			os.Exit(1)
+		}
+	}
+
 	transitClient, err := transit.NewTransitClient(logger)
 	if err != nil {
 		logger.Error("Error creating transit client", "error", err)
 		os.Exit(1)
 	}
 
+	if importManifest != "" {
+		importManifestRun(logger, transitClient)
+		return
+	}
+
 	// set key properties
 	transitClient.SetKeyProperties(transitMount, transitKey)
+	transitClient.SetImportOptions(transit.ImportOptions{
+		NewVersion:           importAsNewVersion,
+		Verify:               importVerify,
+		HashFunction:         importHashFunction,
+		Exportable:           importExportable,
+		AllowPlaintextBackup: importAllowPlaintextBackup,
+		AllowRotation:        importAllowRotation,
+		AutoRotatePeriod:     importAutoRotatePeriod,
+		Derived:              importDerived,
+		Context:              importContext,
+	})
 
-	err = transitClient.ImportPrivateKey(privKey)
+	err = transitClient.Import(src)
 	if err != nil {
 		logger.Error("Error importing key", "error", err)
 		os.Exit(1)
@@ -75,3 +235,39 @@ func importRun(cmd *cobra.Command, args []string) {
 	apiPath := fmt.Sprintf("%s/keys/%s", transitMount, transitKey)
 	logger.Info("Import successful", "path", apiPath)
 }
+
+// importManifestRun imports all keys from manifest, prints a summary
+// table and exits non zero if any import failed
+func importManifestRun(logger hclog.Logger, transitClient *transit.TransitClient) {
+
+	manifest, err := transit.LoadKeyManifest(importManifest)
+	if err != nil {
+		logger.Error("Error loading manifest", "error", err)
+		os.Exit(1)
+	}
+
+	results := transitClient.ImportManifest(manifest, transitMount)
+
+	failed := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PATH\tSTATUS\tERROR")
+	for _, r := range results {
+		status := "OK"
+		errMsg := ""
+		if r.Err != nil {
+			status = "FAILED"
+			errMsg = r.Err.Error()
+			failed++
+		}
+		fmt.Fprintf(w, "%s/keys/%s\t%s\t%s\n", r.Mount, r.Name, status, errMsg)
+	}
+	//nolint
+	w.Flush()
+
+	if failed > 0 {
+		logger.Error("Some imports failed", "failed", failed, "total", len(results))
+		os.Exit(1)
+	}
+
+	logger.Info("All imports successful", "total", len(results))
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/logger"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit"
+)
+
+var jwksKeys []string
+var jwksListen string
+var jwksTLSCert string
+var jwksTLSKey string
+var jwksRefreshInterval time.Duration
+var jwksMaxAge time.Duration
+
+func init() {
+	// bind to transit command
+	transitCmd.AddCommand(jwksCmd)
+	// add flags to sub command
+	jwksCmd.Flags().StringArrayVarP(&jwksKeys, "key", "k", []string{}, "Transit key to publish as '[mount/]name[=alg]' (repeatable)")
+	jwksCmd.Flags().StringVarP(&transitMount, "mount", "", "transit", "Default mount path of transit backend")
+	jwksCmd.Flags().StringVarP(&certOut, "out", "o", "", "Output file for the JWKS (default stdout)")
+
+	jwksCmd.AddCommand(jwksServeCmd)
+	jwksServeCmd.Flags().StringArrayVarP(&jwksKeys, "key", "k", []string{}, "Transit key to publish as '[mount/]name[=alg]' (repeatable)")
+	jwksServeCmd.Flags().StringVarP(&transitMount, "mount", "", "transit", "Default mount path of transit backend")
+	jwksServeCmd.Flags().StringVarP(&jwksListen, "listen", "l", ":8080", "Listen address of the JWKS server")
+	jwksServeCmd.Flags().StringVarP(&jwksTLSCert, "tls-cert", "", "", "PEM TLS certificate of the JWKS server (plain HTTP if empty)")
+	jwksServeCmd.Flags().StringVarP(&jwksTLSKey, "tls-key", "", "", "PEM TLS private key of the JWKS server")
+	jwksServeCmd.Flags().DurationVarP(&jwksRefreshInterval, "refresh-interval", "", 5*time.Minute, "Interval between syncs of the transit keys")
+	jwksServeCmd.Flags().DurationVarP(&jwksMaxAge, "max-age", "", 5*time.Minute, "Cache-Control max-age of the JWKS")
+
+	// required flags
+	//nolint
+	jwksCmd.MarkFlagRequired("key")
+	//nolint
+	jwksServeCmd.MarkFlagRequired("key")
+
+	jwksServeCmd.MarkFlagsRequiredTogether("tls-cert", "tls-key")
+
+}
+
+var jwksCmd = &cobra.Command{
+	Use:   "jwks",
+	Short: "Export a JWKS of transit keys",
+	Long:  "Export a JSON Web Key Set with every version (from min_decryption_version to latest_version) of transit keys, with 'kid' '<name>:v<version>'",
+	Run:   jwksRun,
+
+	Example: `
+   hc-vault-util transit jwks --key rsa-key --key ec-key --out jwks.json
+
+   # keys from several mounts, RSA key used with PS256
+   hc-vault-util transit jwks --key transit/rsa-key=PS256 --key oidc/transit/ec-key
+
+Mandatory Environment Variables:
+- VAULT_ADDR: Address of the vault server
+- VAULT_TOKEN: Vault authentication token. With permission to read '[MOUNT]/keys/[KEY-NAME]' for each key.
+
+Optional Environment Variables:
+- VAULT_CACERT: Path to a PEM encoded CA file to verify TLS on the VAULT_ADDR.
+- VAULT_CAPATH: Path to a directory of PEM encoded CA files to verify TLS on the VAULT_ADDR.
+- VAULT_SKIP_VERIFY: To disable TLS verification completely.
+`,
+}
+
+var jwksServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve a JWKS of transit keys",
+	Long:  "Serve a JSON Web Key Set of transit keys on '/.well-known/jwks.json', refreshed from transit on an interval, with a health endpoint on '/healthz'",
+	Run:   jwksServeRun,
+
+	Example: `
+   hc-vault-util transit jwks serve --key rsa-key --key oidc/transit/ec-key --listen :8080 --refresh-interval 1m
+
+   curl http://localhost:8080/.well-known/jwks.json
+   curl http://localhost:8080/healthz
+
+Mandatory Environment Variables:
+- VAULT_ADDR: Address of the vault server
+- VAULT_TOKEN: Vault authentication token. With permission to read '[MOUNT]/keys/[KEY-NAME]' for each key.
+
+Optional Environment Variables:
+- VAULT_CACERT: Path to a PEM encoded CA file to verify TLS on the VAULT_ADDR.
+- VAULT_CAPATH: Path to a directory of PEM encoded CA files to verify TLS on the VAULT_ADDR.
+- VAULT_SKIP_VERIFY: To disable TLS verification completely.
+
+NOTE: if a refresh fails, the last synced JWKS is served and '/healthz' returns 503
+`,
+}
+
+// jwksRun cobra server handler
+func jwksRun(cmd *cobra.Command, args []string) {
+
+	logger := logger.GenLogger(Debug, noColor)
+
+	keys, err := parseJWKSKeys()
+	if err != nil {
+		logger.Error("Invalid key", "error", err)
+		os.Exit(1)
+	}
+
+	transitClient, err := transit.NewTransitClient(logger)
+	if err != nil {
+		logger.Error("Error creating transit client", "error", err)
+		os.Exit(1)
+	}
+
+	err = transitClient.ExportJWKS(keys, certOut)
+	if err != nil {
+		logger.Error("Error exporting JWKS", "error", err)
+		os.Exit(1)
+	}
+
+}
+
+// jwksServeRun cobra server handler
+func jwksServeRun(cmd *cobra.Command, args []string) {
+
+	logger := logger.GenLogger(Debug, noColor)
+
+	keys, err := parseJWKSKeys()
+	if err != nil {
+		logger.Error("Invalid key", "error", err)
+		os.Exit(1)
+	}
+
+	if jwksRefreshInterval <= 0 {
+		logger.Error("Invalid refresh interval", "refresh_interval", jwksRefreshInterval)
+		os.Exit(1)
+	}
+
+	transitClient, err := transit.NewTransitClient(logger)
+	if err != nil {
+		logger.Error("Error creating transit client", "error", err)
+		os.Exit(1)
+	}
+
+	// graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	opts := transit.JWKSServerOptions{
+		Addr:            jwksListen,
+		TLSCertFile:     jwksTLSCert,
+		TLSKeyFile:      jwksTLSKey,
+		RefreshInterval: jwksRefreshInterval,
+		MaxAge:          jwksMaxAge,
+	}
+
+	err = transitClient.ServeJWKS(ctx, keys, opts)
+	if err != nil {
+		logger.Error("Error serving JWKS", "error", err)
+		os.Exit(1)
+	}
+
+}
+
+// parseJWKSKeys parses the --key flags
+func parseJWKSKeys() ([]transit.JWKSKey, error) {
+
+	keys := make([]transit.JWKSKey, 0, len(jwksKeys))
+	for _, ref := range jwksKeys {
+		k, err := transit.ParseJWKSKey(ref, transitMount)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+
+	return keys, nil
+}
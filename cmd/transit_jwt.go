@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/logger"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit"
+)
+
+var jwtAlg string
+var jwtTyp string
+var jwtClaims string
+var jwtExpiresIn string
+var jwtToken string
+var jwtViaTransit bool
+var jwtIgnoreTimeClaims bool
+
+func init() {
+	// bind to transit command
+	transitCmd.AddCommand(jwtCmd)
+	jwtCmd.AddCommand(jwtSignCmd)
+	jwtCmd.AddCommand(jwtVerifyCmd)
+
+	// add flags to sub command
+	jwtSignCmd.Flags().StringVarP(&transitKey, "transit-key", "t", "", "The name of the transit key")
+	jwtSignCmd.Flags().StringVarP(&transitMount, "mount", "", "transit", "Mount path of transit backend")
+	jwtSignCmd.Flags().IntVarP(&keyVersion, "version", "", 0, "Version of the transit key, or 0 for latest (default 0)")
+	jwtSignCmd.Flags().StringVarP(&jwtAlg, "alg", "", "", "JWS algorithm (default RS256 for RSA, ES256/ES384/ES512 for ECDSA, EdDSA for ed25519)")
+	jwtSignCmd.Flags().StringVarP(&jwtTyp, "typ", "", "JWT", "JOSE 'typ' header")
+	jwtSignCmd.Flags().StringVarP(&jwtClaims, "claims", "c", "-", "JSON claims file, or '-' for stdin")
+	jwtSignCmd.Flags().StringVarP(&jwtExpiresIn, "expires-in", "", "", "Set 'iat' and 'exp' claims (e.g. 1h)")
+	jwtSignCmd.Flags().StringVarP(&certOut, "out", "o", "", "Output file for the JWT (default stdout)")
+
+	jwtVerifyCmd.Flags().StringVarP(&transitKey, "transit-key", "t", "", "The name of the transit key")
+	jwtVerifyCmd.Flags().StringVarP(&transitMount, "mount", "", "transit", "Mount path of transit backend")
+	jwtVerifyCmd.Flags().StringVarP(&jwtToken, "token", "", "-", "JWT file, or '-' for stdin")
+	jwtVerifyCmd.Flags().BoolVarP(&jwtViaTransit, "via-transit", "", false, "Verify the signature with transit verify API instead of locally with the transit public keys")
+	jwtVerifyCmd.Flags().BoolVarP(&jwtIgnoreTimeClaims, "ignore-time-claims", "", false, "Do not check 'exp' and 'nbf' claims")
+
+	// required flags
+	//nolint
+	jwtSignCmd.MarkFlagRequired("transit-key")
+	//nolint
+	jwtVerifyCmd.MarkFlagRequired("transit-key")
+
+}
+
+var jwtCmd = &cobra.Command{
+	Use:   "jwt",
+	Short: "Commands for JWT signed with transit keys",
+	Run: func(cmd *cobra.Command, args []string) {
+
+		// command does nothing
+		err := cmd.Help()
+		if err != nil {
+			log.Fatal(err)
+		}
+		os.Exit(1)
+	},
+}
+
+var jwtSignCmd = &cobra.Command{
+	Use:   "sign",
+	Short: "Sign a JWT with private key in transit backend",
+	Long:  "Sign JSON claims as a compact JWT with private key in transit backend, with 'kid' header '<transit-key>:v<version>'",
+	Run:   jwtSignRun,
+
+	Example: `
+   echo '{"sub":"my-service","aud":"api"}' | hc-vault-util transit jwt sign --transit-key "ec-key" --expires-in 1h
+
+   # RSA PSS
+   hc-vault-util transit jwt sign --transit-key "rsa-key" --alg PS256 --claims claims.json --out token.jwt
+
+Mandatory Environment Variables:
+- VAULT_ADDR: Address of the vault server
+- VAULT_TOKEN: Vault authentication token. With permission to read 'transit/keys/[KEY-NAME]' and write 'transit/sign/[KEY-NAME]'.
+
+Optional Environment Variables:
+- VAULT_CACERT: Path to a PEM encoded CA file to verify TLS on the VAULT_ADDR.
+- VAULT_CAPATH: Path to a directory of PEM encoded CA files to verify TLS on the VAULT_ADDR.
+- VAULT_SKIP_VERIFY: To disable TLS verification completely.
+
+Algorithms: RS256, RS384, RS512, PS256, PS384, PS512 (rsa-*), ES256 (ecdsa-p256), 
+ES384 (ecdsa-p384), ES512 (ecdsa-p521), EdDSA (ed25519)
+
+NOTE: PS* algorithms require Vault 1.14+ (salt_length)
+`,
+}
+
+var jwtVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify a JWT signed with private key in transit backend",
+	Long:  "Verify a compact JWT signed with private key in transit backend, and print its claims",
+	Run:   jwtVerifyRun,
+
+	Example: `
+   hc-vault-util transit jwt verify --transit-key "ec-key" --token token.jwt
+
+   # verify with transit verify API
+   cat token.jwt | hc-vault-util transit jwt verify --transit-key "ec-key" --via-transit
+
+Mandatory Environment Variables:
+- VAULT_ADDR: Address of the vault server
+- VAULT_TOKEN: Vault authentication token. With permission to read 'transit/keys/[KEY-NAME]'.
+  (with --via-transit: and write 'transit/verify/[KEY-NAME]')
+
+Optional Environment Variables:
+- VAULT_CACERT: Path to a PEM encoded CA file to verify TLS on the VAULT_ADDR.
+- VAULT_CAPATH: Path to a directory of PEM encoded CA files to verify TLS on the VAULT_ADDR.
+- VAULT_SKIP_VERIFY: To disable TLS verification completely.
+`,
+}
+
+// jwtSignRun cobra server handler
+func jwtSignRun(cmd *cobra.Command, args []string) {
+
+	logger := logger.GenLogger(Debug, noColor)
+
+	var expiresIn time.Duration
+	if jwtExpiresIn != "" {
+		var err error
+		expiresIn, err = time.ParseDuration(jwtExpiresIn)
+		if err != nil || expiresIn <= 0 {
+			logger.Error("Invalid expires in", "expires_in", jwtExpiresIn, "error", err)
+			os.Exit(1)
+		}
+	}
+
+	transitClient, err := transit.NewTransitClient(logger)
+	if err != nil {
+		logger.Error("Error creating transit client", "error", err)
+		os.Exit(1)
+	}
+
+	// set key properties
+	transitClient.SetKeyProperties(transitMount, transitKey)
+
+	opts := transit.JWTOptions{
+		Alg:       jwtAlg,
+		Typ:       jwtTyp,
+		ExpiresIn: expiresIn,
+	}
+
+	err = transitClient.SignJWT(jwtClaims, keyVersion, opts, certOut)
+	if err != nil {
+		logger.Error("Error signing JWT", "error", err)
+		os.Exit(1)
+	}
+
+}
+
+// jwtVerifyRun cobra server handler
+func jwtVerifyRun(cmd *cobra.Command, args []string) {
+
+	logger := logger.GenLogger(Debug, noColor)
+
+	transitClient, err := transit.NewTransitClient(logger)
+	if err != nil {
+		logger.Error("Error creating transit client", "error", err)
+		os.Exit(1)
+	}
+
+	// set key properties
+	transitClient.SetKeyProperties(transitMount, transitKey)
+
+	err = transitClient.VerifyJWT(jwtToken, jwtViaTransit, jwtIgnoreTimeClaims)
+	if err != nil {
+		logger.Error("Error verifying JWT", "error", err)
+		os.Exit(1)
+	}
+
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/logger"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit"
+)
+
+var ocspRequest string
+var ocspSerial string
+var ocspNextUpdate string
+
+func init() {
+	// bind to transit command
+	transitCmd.AddCommand(ocspCmd)
+	// add flags to sub command
+	ocspCmd.Flags().StringVarP(&caCertFile, "ca-cert", "", "", "The PEM encoded CA certificate of the transit key")
+	ocspCmd.Flags().StringVarP(&transitKey, "transit-key", "t", "", "The name of the transit key of the CA")
+	ocspCmd.Flags().StringVarP(&transitMount, "mount", "", "transit", "Mount path of transit backend")
+	ocspCmd.Flags().IntVarP(&keyVersion, "version", "", 0, "Version of the transit key, or 0 for latest (default 0)")
+	ocspCmd.Flags().StringVarP(&revokedFile, "revoked", "", "", "YAML list of revoked and issued certificates (see 'transit crl --help')")
+	ocspCmd.Flags().StringVarP(&ocspRequest, "request", "", "", "The DER encoded OCSP request")
+	ocspCmd.Flags().StringVarP(&ocspSerial, "serial", "", "", "Serial number of the certificate (decimal, 0x prefixed hex, or ':' separated hex)")
+	ocspCmd.Flags().StringVarP(&ocspNextUpdate, "next-update", "", "24h", "Validity of the OCSP response")
+	ocspCmd.Flags().StringVarP(&certOut, "out", "o", "", "Output file for the DER encoded OCSP response (default stdout)")
+
+	// required flags
+	//nolint
+	ocspCmd.MarkFlagRequired("transit-key")
+	//nolint
+	ocspCmd.MarkFlagRequired("ca-cert")
+	//nolint
+	ocspCmd.MarkFlagRequired("revoked")
+
+	ocspCmd.MarkFlagsMutuallyExclusive("request", "serial")
+
+}
+
+var ocspCmd = &cobra.Command{
+	Use:   "ocsp",
+	Short: "Generate an OCSP response signed with a CA private key in transit backend",
+	Long:  "Generate an OCSP response from a list of revoked and issued certificates, signed by a CA certificate whose private key (RSA or ECDSA) is in transit backend. The status is 'revoked' for a revoked certificate, 'good' for an issued certificate, and 'unknown' otherwise.",
+	Run:   ocspRun,
+
+	Example: `
+   # response for an OCSP request (e.g. 'openssl ocsp -issuer ca.pem -cert cert.pem -reqout req.der')
+   hc-vault-util transit ocsp --ca-cert ca.pem --transit-key "ca" --revoked revoked.yaml --request req.der --out resp.der
+
+   # pre-generate response for a serial number
+   hc-vault-util transit ocsp --ca-cert ca.pem --transit-key "ca" --revoked revoked.yaml --serial 0x1f3a9c --out resp.der
+
+Mandatory Environment Variables:
+- VAULT_ADDR: Address of the vault server
+- VAULT_TOKEN: Vault authentication token. With permission to read 'transit/keys/[KEY-NAME]' and write 'transit/sign/[KEY-NAME]'.
+
+Optional Environment Variables:
+- VAULT_CACERT: Path to a PEM encoded CA file to verify TLS on the VAULT_ADDR.
+- VAULT_CAPATH: Path to a directory of PEM encoded CA files to verify TLS on the VAULT_ADDR.
+- VAULT_SKIP_VERIFY: To disable TLS verification completely.
+`,
+}
+
+// ocspRun cobra server handler
+func ocspRun(cmd *cobra.Command, args []string) {
+
+	logger := logger.GenLogger(Debug, noColor)
+
+	if ocspRequest == "" && ocspSerial == "" {
+		logger.Error("one of --request or --serial is required")
+		os.Exit(1)
+	}
+
+	validity, err := time.ParseDuration(ocspNextUpdate)
+	if err != nil || validity <= 0 {
+		logger.Error("Invalid next update", "next_update", ocspNextUpdate, "error", err)
+		os.Exit(1)
+	}
+
+	var serial *big.Int
+	if ocspSerial != "" {
+		serial, err = transit.ParseSerialNumber(ocspSerial)
+		if err != nil {
+			logger.Error("Invalid serial number", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	transitClient, err := transit.NewTransitClient(logger)
+	if err != nil {
+		logger.Error("Error creating transit client", "error", err)
+		os.Exit(1)
+	}
+
+	// set key properties
+	transitClient.SetKeyProperties(transitMount, transitKey)
+
+	opts := transit.OCSPOptions{
+		NextUpdate: validity,
+	}
+
+	err = transitClient.GenOCSPResponse(caCertFile, keyVersion, revokedFile, ocspRequest, serial, opts, certOut)
+	if err != nil {
+		logger.Error("Error generating OCSP response", "error", err)
+		os.Exit(1)
+	}
+
+}
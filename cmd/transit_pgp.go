@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/logger"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit"
+)
+
+var pgpUserID string
+var pgpArmor bool
+
+func init() {
+	// bind to transit command
+	transitCmd.AddCommand(pgpCmd)
+	pgpCmd.AddCommand(pgpPubKeyCmd)
+	pgpCmd.AddCommand(pgpSignCmd)
+
+	// add flags to sub command
+	pgpPubKeyCmd.Flags().StringVarP(&transitKey, "transit-key", "t", "", "The name of the transit key")
+	pgpPubKeyCmd.Flags().StringVarP(&transitMount, "mount", "", "transit", "Mount path of transit backend")
+	pgpPubKeyCmd.Flags().IntVarP(&keyVersion, "version", "", 0, "Version of the transit key, or 0 for latest (default 0)")
+	pgpPubKeyCmd.Flags().StringVarP(&pgpUserID, "user-id", "u", "", "OpenPGP user ID (e.g. 'Release Signing <release@example.com>')")
+	pgpPubKeyCmd.Flags().BoolVarP(&pgpArmor, "armor", "", true, "ASCII armored output")
+	pgpPubKeyCmd.Flags().StringVarP(&certOut, "out", "o", "", "Output file for the OpenPGP public key (default stdout)")
+
+	pgpSignCmd.Flags().StringVarP(&signFile, "file", "f", "", "File to sign, or '-' for stdin")
+	pgpSignCmd.Flags().StringVarP(&transitKey, "transit-key", "t", "", "The name of the transit key")
+	pgpSignCmd.Flags().StringVarP(&transitMount, "mount", "", "transit", "Mount path of transit backend")
+	pgpSignCmd.Flags().IntVarP(&keyVersion, "version", "", 0, "Version of the transit key, or 0 for latest (default 0)")
+	pgpSignCmd.Flags().BoolVarP(&pgpArmor, "armor", "", true, "ASCII armored output")
+	pgpSignCmd.Flags().StringVarP(&certOut, "out", "o", "", "Output file for the detached signature (default stdout)")
+
+	// required flags
+	//nolint
+	pgpPubKeyCmd.MarkFlagRequired("transit-key")
+	//nolint
+	pgpPubKeyCmd.MarkFlagRequired("user-id")
+	//nolint
+	pgpSignCmd.MarkFlagRequired("transit-key")
+	//nolint
+	pgpSignCmd.MarkFlagRequired("file")
+
+}
+
+var pgpCmd = &cobra.Command{
+	Use:   "pgp",
+	Short: "Commands for OpenPGP keys and signatures with transit keys",
+	Run: func(cmd *cobra.Command, args []string) {
+
+		// command does nothing
+		err := cmd.Help()
+		if err != nil {
+			log.Fatal(err)
+		}
+		os.Exit(1)
+	},
+}
+
+var pgpPubKeyCmd = &cobra.Command{
+	Use:   "pubkey",
+	Short: "Export the OpenPGP public key of a transit key",
+	Long:  "Export the OpenPGP public key of a transit RSA, ECDSA or ed25519 key version, with a user ID self certified by the transit key. The key creation time is the transit key version 'creation_time', so the fingerprint does not change between exports.",
+	Run:   pgpPubKeyRun,
+
+	Example: `
+   hc-vault-util transit pgp pubkey --transit-key "release" --user-id "Release Signing <release@example.com>" --out release.asc
+
+   gpg --import release.asc
+
+Mandatory Environment Variables:
+- VAULT_ADDR: Address of the vault server
+- VAULT_TOKEN: Vault authentication token. With permission to read 'transit/keys/[KEY-NAME]' and write 'transit/sign/[KEY-NAME]'.
+
+Optional Environment Variables:
+- VAULT_CACERT: Path to a PEM encoded CA file to verify TLS on the VAULT_ADDR.
+- VAULT_CAPATH: Path to a directory of PEM encoded CA files to verify TLS on the VAULT_ADDR.
+- VAULT_SKIP_VERIFY: To disable TLS verification completely.
+`,
+}
+
+var pgpSignCmd = &cobra.Command{
+	Use:   "sign",
+	Short: "Create an OpenPGP detached signature with private key in transit backend",
+	Long:  "Create an OpenPGP detached signature of a file, hashed locally and signed with private key in transit backend",
+	Run:   pgpSignRun,
+
+	Example: `
+   hc-vault-util transit pgp sign --transit-key "release" --file Release --out Release.gpg
+
+   gpg --verify Release.gpg Release
+
+Mandatory Environment Variables:
+- VAULT_ADDR: Address of the vault server
+- VAULT_TOKEN: Vault authentication token. With permission to read 'transit/keys/[KEY-NAME]' and write 'transit/sign/[KEY-NAME]'.
+
+Optional Environment Variables:
+- VAULT_CACERT: Path to a PEM encoded CA file to verify TLS on the VAULT_ADDR.
+- VAULT_CAPATH: Path to a directory of PEM encoded CA files to verify TLS on the VAULT_ADDR.
+- VAULT_SKIP_VERIFY: To disable TLS verification completely.
+`,
+}
+
+// pgpPubKeyRun cobra server handler
+func pgpPubKeyRun(cmd *cobra.Command, args []string) {
+
+	logger := logger.GenLogger(Debug, noColor)
+
+	transitClient, err := transit.NewTransitClient(logger)
+	if err != nil {
+		logger.Error("Error creating transit client", "error", err)
+		os.Exit(1)
+	}
+
+	// set key properties
+	transitClient.SetKeyProperties(transitMount, transitKey)
+
+	err = transitClient.ExportPGPPublicKey(keyVersion, pgpUserID, pgpArmor, certOut)
+	if err != nil {
+		logger.Error("Error exporting OpenPGP public key", "error", err)
+		os.Exit(1)
+	}
+
+}
+
+// pgpSignRun cobra server handler
+func pgpSignRun(cmd *cobra.Command, args []string) {
+
+	logger := logger.GenLogger(Debug, noColor)
+
+	transitClient, err := transit.NewTransitClient(logger)
+	if err != nil {
+		logger.Error("Error creating transit client", "error", err)
+		os.Exit(1)
+	}
+
+	// set key properties
+	transitClient.SetKeyProperties(transitMount, transitKey)
+
+	err = transitClient.PGPSignFile(signFile, keyVersion, pgpArmor, certOut)
+	if err != nil {
+		logger.Error("Error signing file", "error", err)
+		os.Exit(1)
+	}
+
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/logger"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit"
+)
+
+var pubKeyFormat string
+var pubKeyAll bool
+
+func init() {
+	// bind to transit command
+	transitCmd.AddCommand(pubKeyCmd)
+	// add flags to sub command
+	pubKeyCmd.Flags().StringVarP(&transitKey, "transit-key", "t", "", "The name of the transit key")
+	pubKeyCmd.Flags().StringVarP(&transitMount, "mount", "", "transit", "Mount path of transit backend")
+	pubKeyCmd.Flags().IntVarP(&keyVersion, "version", "", 0, "Version of the transit key, or 0 for latest (default 0)")
+	pubKeyCmd.Flags().BoolVarP(&pubKeyAll, "all", "", false, "Export every version of the transit key")
+	pubKeyCmd.Flags().StringVarP(&pubKeyFormat, "format", "f", "pem", "Output format: pem, der, ssh or jwk")
+	pubKeyCmd.Flags().StringVarP(&certOut, "out", "o", "", "Output file for the public key (default stdout)")
+
+	// required flags
+	//nolint
+	pubKeyCmd.MarkFlagRequired("transit-key")
+
+	pubKeyCmd.MarkFlagsMutuallyExclusive("version", "all")
+
+}
+
+var pubKeyCmd = &cobra.Command{
+	Use:   "pubkey",
+	Short: "Export the public key of a transit key",
+	Long:  "List the versions of a transit key with their SHA-256 fingerprints, and export the public key of a version (or every version) as PKIX PEM, DER, OpenSSH authorized_keys or JWK",
+	Run:   pubKeyRun,
+
+	Example: `
+   hc-vault-util transit pubkey --transit-key "my-key" --out my-key.pem
+
+   # version 2 as DER
+   hc-vault-util transit pubkey --transit-key "my-key" --version 2 --format der --out my-key.der
+
+   # every version as authorized_keys lines (comment '<name>:v<version>')
+   hc-vault-util transit pubkey --transit-key "my-key" --all --format ssh >> ~/.ssh/authorized_keys
+
+   # every version as a JWKS
+   hc-vault-util transit pubkey --transit-key "my-key" --all --format jwk
+
+Mandatory Environment Variables:
+- VAULT_ADDR: Address of the vault server
+- VAULT_TOKEN: Vault authentication token. With permission to read 'transit/keys/[KEY-NAME]'.
+
+Optional Environment Variables:
+- VAULT_CACERT: Path to a PEM encoded CA file to verify TLS on the VAULT_ADDR.
+- VAULT_CAPATH: Path to a directory of PEM encoded CA files to verify TLS on the VAULT_ADDR.
+- VAULT_SKIP_VERIFY: To disable TLS verification completely.
+`,
+}
+
+// pubKeyRun cobra server handler
+func pubKeyRun(cmd *cobra.Command, args []string) {
+
+	logger := logger.GenLogger(Debug, noColor)
+
+	transitClient, err := transit.NewTransitClient(logger)
+	if err != nil {
+		logger.Error("Error creating transit client", "error", err)
+		os.Exit(1)
+	}
+
+	// set key properties
+	transitClient.SetKeyProperties(transitMount, transitKey)
+
+	err = transitClient.ExportPublicKey(keyVersion, pubKeyAll, pubKeyFormat, certOut)
+	if err != nil {
+		logger.Error("Error exporting public key", "error", err)
+		os.Exit(1)
+	}
+
+}
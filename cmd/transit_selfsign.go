@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/logger"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit"
+)
+
+var certExpiry string
+var certKeyUsage []string
+var certExtKeyUsage []string
+var certIsCA bool
+var certMaxPathLen int
+var certOut string
+
+func init() {
+	// bind to transit command
+	transitCmd.AddCommand(selfSignCmd)
+	// add flags to sub command
+	selfSignCmd.Flags().StringVarP(&cfsslCSRFile, "csr-json", "c", "", "The path to a cfssl csr file")
+	selfSignCmd.Flags().StringVarP(&transitKey, "transit-key", "t", "", "The name of the transit key")
+	selfSignCmd.Flags().StringVarP(&transitMount, "mount", "", "transit", "Mount path of transit backend")
+	selfSignCmd.Flags().IntVarP(&keyVersion, "version", "", 0, "Version of the transit key, or 0 for latest (default 0)")
+	selfSignCmd.Flags().StringVarP(&certExpiry, "expiry", "", "8760h", "Validity of the certificate")
+	selfSignCmd.Flags().StringSliceVarP(&certKeyUsage, "key-usage", "", []string{}, "Key usages (cfssl names) (default 'cert sign,crl sign' for CA, 'signing,key encipherment' otherwise)")
+	selfSignCmd.Flags().StringSliceVarP(&certExtKeyUsage, "ext-key-usage", "", []string{}, "Extended key usages (cfssl names, e.g. 'server auth,client auth')")
+	selfSignCmd.Flags().BoolVarP(&certIsCA, "is-ca", "", false, "Basic constraints CA")
+	selfSignCmd.Flags().IntVarP(&certMaxPathLen, "max-path-len", "", -1, "Basic constraints max path length for CA, or -1 for unlimited")
+	selfSignCmd.Flags().StringVarP(&certOut, "out", "o", "", "Output file for the PEM encoded certificate (default stdout)")
+
+	// required flags
+	//nolint
+	selfSignCmd.MarkFlagRequired("transit-key")
+	//nolint
+	selfSignCmd.MarkFlagRequired("csr-json")
+
+}
+
+var selfSignCmd = &cobra.Command{
+	Use:   "selfsign",
+	Short: "Generate a self-signed certificate from private key in transit backend",
+	Long:  "Generate a self-signed X.509 certificate from a cfssl csr file, signed with private key in transit backend",
+	Run:   selfSignRun,
+
+	Example: `
+   # internal root CA
+   hc-vault-util transit selfsign --csr-json example/csr.json --transit-key "root-ca" --is-ca --max-path-len 1 --expiry 87600h --out root-ca.pem
+
+   # self-signed server certificate
+   hc-vault-util transit selfsign --csr-json example/csr.json --transit-key "rsa" --ext-key-usage "server auth"
+
+Mandatory Environment Variables:
+- VAULT_ADDR: Address of the vault server 
+- VAULT_TOKEN: Vault authentication token. With permission to read 'transit/keys/[KEY-NAME]' and write 'transit/sign/[KEY-NAME]'.
+
+Optional Environment Variables:
+- VAULT_CACERT: Path to a PEM encoded CA file to verify TLS on the VAULT_ADDR.
+- VAULT_CAPATH: Path to a directory of PEM encoded CA files to verify TLS on the VAULT_ADDR.
+- VAULT_SKIP_VERIFY: To disable TLS verification completely.
+
+Key usages: signing, digital signature, content commitment, key encipherment, key agreement, 
+data encipherment, cert sign, crl sign, encipher only, decipher only
+
+Extended key usages: any, server auth, client auth, code signing, email protection, s/mime, 
+ipsec end system, ipsec tunnel, ipsec user, timestamping, ocsp signing, microsoft sgc, netscape sgc
+`,
+}
+
+// selfSignRun cobra server handler
+func selfSignRun(cmd *cobra.Command, args []string) {
+
+	logger := logger.GenLogger(Debug, noColor)
+
+	opts, err := certificateOptions(cmd)
+	if err != nil {
+		logger.Error("Invalid certificate options", "error", err)
+		os.Exit(1)
+	}
+
+	transitClient, err := transit.NewTransitClient(logger)
+	if err != nil {
+		logger.Error("Error creating transit client", "error", err)
+		os.Exit(1)
+	}
+
+	// set key properties
+	transitClient.SetKeyProperties(transitMount, transitKey)
+
+	err = transitClient.SelfSign(cfsslCSRFile, keyVersion, opts, certOut)
+	if err != nil {
+		logger.Error("Error generating self-signed certificate", "error", err)
+		os.Exit(1)
+	}
+
+}
+
+// certificateOptions returns the certificate options from the flags, with
+// default key usages for CA or end entity certificates
+func certificateOptions(cmd *cobra.Command) (transit.CertificateOptions, error) {
+
+	expiry, err := time.ParseDuration(certExpiry)
+	if err != nil {
+		return transit.CertificateOptions{}, err
+	}
+	if expiry <= 0 {
+		return transit.CertificateOptions{}, fmt.Errorf("expiry must be positive")
+	}
+
+	keyUsage := certKeyUsage
+	if !cmd.Flags().Changed("key-usage") {
+		keyUsage = []string{"signing", "key encipherment"}
+		if certIsCA {
+			keyUsage = []string{"cert sign", "crl sign"}
+		}
+	}
+
+	return transit.CertificateOptions{
+		Expiry:      expiry,
+		KeyUsage:    keyUsage,
+		ExtKeyUsage: certExtKeyUsage,
+		IsCA:        certIsCA,
+		MaxPathLen:  certMaxPathLen,
+	}, nil
+}
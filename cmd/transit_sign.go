@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/logger"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit"
+)
+
+var signFile string
+var signatureFile string
+var signHashAlg string
+var signSigAlg string
+var signFormat string
+
+func init() {
+	// bind to transit command
+	transitCmd.AddCommand(signCmd)
+	transitCmd.AddCommand(verifyCmd)
+
+	// add flags to sub command
+	signCmd.Flags().StringVarP(&signFile, "file", "f", "", "File to sign, or '-' for stdin")
+	signCmd.Flags().StringVarP(&transitKey, "transit-key", "t", "", "The name of the transit key")
+	signCmd.Flags().StringVarP(&transitMount, "mount", "", "transit", "Mount path of transit backend")
+	signCmd.Flags().IntVarP(&keyVersion, "version", "", 0, "Version of the transit key, or 0 for latest (default 0)")
+	signCmd.Flags().StringVarP(&signHashAlg, "hash", "", "sha2-256", "Hash algorithm: sha2-224, sha2-256, sha2-384, sha2-512, sha3-224, sha3-256, sha3-384 or sha3-512")
+	signCmd.Flags().StringVarP(&signSigAlg, "signature-algorithm", "", "", "Signature algorithm for RSA keys: pss or pkcs1v15 (default pkcs1v15)")
+	signCmd.Flags().StringVarP(&signFormat, "format", "", "vault", "Signature format: vault ('vault:v<version>:<base64>'), base64 or raw")
+	signCmd.Flags().StringVarP(&certOut, "out", "o", "", "Output file for the signature (default stdout)")
+
+	verifyCmd.Flags().StringVarP(&signFile, "file", "f", "", "Signed file, or '-' for stdin")
+	verifyCmd.Flags().StringVarP(&signatureFile, "signature", "s", "", "Signature file, or '-' for stdin")
+	verifyCmd.Flags().StringVarP(&transitKey, "transit-key", "t", "", "The name of the transit key")
+	verifyCmd.Flags().StringVarP(&transitMount, "mount", "", "transit", "Mount path of transit backend")
+	verifyCmd.Flags().IntVarP(&keyVersion, "version", "", 0, "Version of the transit key for base64 and raw signatures, or 0 for latest (default 0)")
+	verifyCmd.Flags().StringVarP(&signHashAlg, "hash", "", "sha2-256", "Hash algorithm: sha2-224, sha2-256, sha2-384, sha2-512, sha3-224, sha3-256, sha3-384 or sha3-512")
+	verifyCmd.Flags().StringVarP(&signSigAlg, "signature-algorithm", "", "", "Signature algorithm for RSA keys: pss or pkcs1v15 (default pkcs1v15)")
+	verifyCmd.Flags().StringVarP(&signFormat, "format", "", "vault", "Signature format: vault ('vault:v<version>:<base64>'), base64 or raw")
+
+	// required flags
+	//nolint
+	signCmd.MarkFlagRequired("transit-key")
+	//nolint
+	signCmd.MarkFlagRequired("file")
+	//nolint
+	verifyCmd.MarkFlagRequired("transit-key")
+	//nolint
+	verifyCmd.MarkFlagRequired("file")
+	//nolint
+	verifyCmd.MarkFlagRequired("signature")
+
+}
+
+var signCmd = &cobra.Command{
+	Use:   "sign",
+	Short: "Sign a file with private key in transit backend",
+	Long:  "Hash a file locally and sign its digest with private key in transit backend (prehashed), and output a detached signature",
+	Run:   signRun,
+
+	Example: `
+   hc-vault-util transit sign --transit-key "release" --file app.tar.gz --out app.tar.gz.sig
+
+   # RSA PSS with SHA-512, raw signature
+   hc-vault-util transit sign --transit-key "rsa-key" --file app.tar.gz --hash sha2-512 --signature-algorithm pss --format raw --out app.tar.gz.sig
+
+Mandatory Environment Variables:
+- VAULT_ADDR: Address of the vault server
+- VAULT_TOKEN: Vault authentication token. With permission to read 'transit/keys/[KEY-NAME]' and write 'transit/sign/[KEY-NAME]'.
+
+Optional Environment Variables:
+- VAULT_CACERT: Path to a PEM encoded CA file to verify TLS on the VAULT_ADDR.
+- VAULT_CAPATH: Path to a directory of PEM encoded CA files to verify TLS on the VAULT_ADDR.
+- VAULT_SKIP_VERIFY: To disable TLS verification completely.
+
+Note: ed25519 keys do not support prehashed signatures.
+`,
+}
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify a file signature with transit backend",
+	Long:  "Hash a file locally and verify its detached signature with transit backend (prehashed)",
+	Run:   verifyRun,
+
+	Example: `
+   hc-vault-util transit verify --transit-key "release" --file app.tar.gz --signature app.tar.gz.sig
+
+   # raw signature of version 2
+   hc-vault-util transit verify --transit-key "rsa-key" --file app.tar.gz --signature app.tar.gz.sig --hash sha2-512 --signature-algorithm pss --format raw --version 2
+
+Mandatory Environment Variables:
+- VAULT_ADDR: Address of the vault server
+- VAULT_TOKEN: Vault authentication token. With permission to read 'transit/keys/[KEY-NAME]' and write 'transit/verify/[KEY-NAME]'.
+
+Optional Environment Variables:
+- VAULT_CACERT: Path to a PEM encoded CA file to verify TLS on the VAULT_ADDR.
+- VAULT_CAPATH: Path to a directory of PEM encoded CA files to verify TLS on the VAULT_ADDR.
+- VAULT_SKIP_VERIFY: To disable TLS verification completely.
+`,
+}
+
+// signRun cobra server handler
+func signRun(cmd *cobra.Command, args []string) {
+
+	logger := logger.GenLogger(Debug, noColor)
+
+	transitClient, err := transit.NewTransitClient(logger)
+	if err != nil {
+		logger.Error("Error creating transit client", "error", err)
+		os.Exit(1)
+	}
+
+	// set key properties
+	transitClient.SetKeyProperties(transitMount, transitKey)
+
+	opts := transit.SignFileOptions{
+		HashAlg: signHashAlg,
+		SigAlg:  signSigAlg,
+		Format:  signFormat,
+	}
+
+	err = transitClient.SignFile(signFile, keyVersion, opts, certOut)
+	if err != nil {
+		logger.Error("Error signing file", "error", err)
+		os.Exit(1)
+	}
+
+}
+
+// verifyRun cobra server handler
+func verifyRun(cmd *cobra.Command, args []string) {
+
+	logger := logger.GenLogger(Debug, noColor)
+
+	transitClient, err := transit.NewTransitClient(logger)
+	if err != nil {
+		logger.Error("Error creating transit client", "error", err)
+		os.Exit(1)
+	}
+
+	// set key properties
+	transitClient.SetKeyProperties(transitMount, transitKey)
+
+	opts := transit.SignFileOptions{
+		HashAlg: signHashAlg,
+		SigAlg:  signSigAlg,
+		Format:  signFormat,
+	}
+
+	err = transitClient.VerifyFile(signFile, signatureFile, keyVersion, opts)
+	if err != nil {
+		logger.Error("Error verifying signature", "error", err)
+		os.Exit(1)
+	}
+
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/cloudflare/cfssl/config"
+	"github.com/spf13/cobra"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/logger"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit"
+)
+
+var csrFile string
+var caCertFile string
+var signingConfig string
+var signingProfile string
+var nameWhitelist string
+var certHosts []string
+
+func init() {
+	// bind to transit command
+	transitCmd.AddCommand(signCertCmd)
+	// add flags to sub command
+	signCertCmd.Flags().StringVarP(&csrFile, "csr", "", "", "The PEM encoded CSR to sign")
+	signCertCmd.Flags().StringVarP(&caCertFile, "ca-cert", "", "", "The PEM encoded CA certificate of the transit key")
+	signCertCmd.Flags().StringVarP(&transitKey, "transit-key", "t", "", "The name of the transit key of the CA")
+	signCertCmd.Flags().StringVarP(&transitMount, "mount", "", "transit", "Mount path of transit backend")
+	signCertCmd.Flags().IntVarP(&keyVersion, "version", "", 0, "Version of the transit key, or 0 for latest (default 0)")
+	signCertCmd.Flags().StringVarP(&signingConfig, "config", "", "", "The cfssl config file with the signing policy")
+	signCertCmd.Flags().StringVarP(&signingProfile, "profile", "", "", "The signing profile of the cfssl config file (default profile if empty)")
+	signCertCmd.Flags().StringVarP(&certExpiry, "expiry", "", "8760h", "Validity of the certificate")
+	signCertCmd.Flags().StringSliceVarP(&certKeyUsage, "key-usage", "", []string{}, "Key usages (cfssl names) (default 'cert sign,crl sign' for CA, 'signing,key encipherment' otherwise)")
+	signCertCmd.Flags().StringSliceVarP(&certExtKeyUsage, "ext-key-usage", "", []string{}, "Extended key usages (cfssl names, e.g. 'server auth,client auth')")
+	signCertCmd.Flags().BoolVarP(&certIsCA, "is-ca", "", false, "Allow issuing an intermediate CA certificate")
+	signCertCmd.Flags().IntVarP(&certMaxPathLen, "max-path-len", "", -1, "Basic constraints max path length for intermediate CA, or -1 for unlimited")
+	signCertCmd.Flags().StringVarP(&nameWhitelist, "name-whitelist", "", "", "Regexp that the common name and SANs of the CSR must match")
+	signCertCmd.Flags().StringSliceVarP(&certHosts, "hosts", "", []string{}, "Override the SANs of the CSR")
+	signCertCmd.Flags().StringVarP(&certOut, "out", "o", "", "Output file for the PEM encoded certificate (default stdout)")
+
+	// required flags
+	//nolint
+	signCertCmd.MarkFlagRequired("transit-key")
+	//nolint
+	signCertCmd.MarkFlagRequired("csr")
+	//nolint
+	signCertCmd.MarkFlagRequired("ca-cert")
+
+	// policy is either from cfssl config or from flags
+	for _, f := range []string{"expiry", "key-usage", "ext-key-usage", "is-ca", "max-path-len", "name-whitelist"} {
+		signCertCmd.MarkFlagsMutuallyExclusive("config", f)
+	}
+
+}
+
+var signCertCmd = &cobra.Command{
+	Use:   "sign-cert",
+	Short: "Issue a certificate from a CSR with a CA private key in transit backend",
+	Long:  "Issue a leaf or intermediate X.509 certificate from a PEM CSR, signed by a CA certificate whose private key is in transit backend, using a cfssl signing policy",
+	Run:   signCertRun,
+
+	Example: `
+   # server certificate, only for *.example.com names
+   hc-vault-util transit sign-cert --csr server.csr --ca-cert ca.pem --transit-key "ca" --ext-key-usage "server auth" --name-whitelist '^([a-z0-9-]+\.)*example\.com$' --expiry 2160h
+
+   # intermediate CA, that cannot issue other CAs
+   hc-vault-util transit sign-cert --csr intermediate.csr --ca-cert root-ca.pem --transit-key "root-ca" --is-ca --max-path-len 0 --expiry 43800h
+
+   # signing profile from cfssl config file
+   hc-vault-util transit sign-cert --csr server.csr --ca-cert ca.pem --transit-key "ca" --config example/cfssl-config.json --profile server
+
+Mandatory Environment Variables:
+- VAULT_ADDR: Address of the vault server
+- VAULT_TOKEN: Vault authentication token. With permission to read 'transit/keys/[KEY-NAME]' and write 'transit/sign/[KEY-NAME]'.
+
+Optional Environment Variables:
+- VAULT_CACERT: Path to a PEM encoded CA file to verify TLS on the VAULT_ADDR.
+- VAULT_CAPATH: Path to a directory of PEM encoded CA files to verify TLS on the VAULT_ADDR.
+- VAULT_SKIP_VERIFY: To disable TLS verification completely.
+`,
+}
+
+// signCertRun cobra server handler
+func signCertRun(cmd *cobra.Command, args []string) {
+
+	logger := logger.GenLogger(Debug, noColor)
+
+	var policy *config.Signing
+	var err error
+	if signingConfig != "" {
+		policy, err = transit.LoadSigningPolicy(signingConfig)
+	} else {
+		var opts transit.CertificateOptions
+		opts, err = certificateOptions(cmd)
+		if err == nil {
+			policy, err = transit.SigningPolicy(opts, nameWhitelist)
+		}
+	}
+	if err != nil {
+		logger.Error("Invalid signing policy", "error", err)
+		os.Exit(1)
+	}
+
+	transitClient, err := transit.NewTransitClient(logger)
+	if err != nil {
+		logger.Error("Error creating transit client", "error", err)
+		os.Exit(1)
+	}
+
+	// set key properties
+	transitClient.SetKeyProperties(transitMount, transitKey)
+
+	signOpts := transit.SignCertOptions{
+		Profile: signingProfile,
+		Hosts:   certHosts,
+	}
+
+	err = transitClient.SignCertificate(csrFile, caCertFile, keyVersion, policy, signOpts, certOut)
+	if err != nil {
+		logger.Error("Error signing certificate", "error", err)
+		os.Exit(1)
+	}
+
+}
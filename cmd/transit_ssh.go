@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/logger"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit"
+)
+
+var sshPublicKey string
+var sshCertType string
+var sshKeyID string
+var sshPrincipals []string
+var sshAllowAnyPrincipal bool
+var sshValidity string
+var sshExtensions []string
+var sshCriticalOptions []string
+
+func init() {
+	// bind to transit command
+	transitCmd.AddCommand(sshCmd)
+	sshCmd.AddCommand(sshSignCertCmd)
+	sshCmd.AddCommand(sshPubKeyCmd)
+
+	// add flags to sub command
+	sshSignCertCmd.Flags().StringVarP(&sshPublicKey, "public-key", "k", "", "The user or host public key to certify (authorized_keys format, e.g. id_ed25519.pub)")
+	sshSignCertCmd.Flags().StringVarP(&transitKey, "transit-key", "t", "", "The name of the transit key of the SSH CA")
+	sshSignCertCmd.Flags().StringVarP(&transitMount, "mount", "", "transit", "Mount path of transit backend")
+	sshSignCertCmd.Flags().IntVarP(&keyVersion, "version", "", 0, "Version of the transit key, or 0 for latest (default 0)")
+	sshSignCertCmd.Flags().StringVarP(&sshCertType, "type", "", "user", "Certificate type: user or host")
+	sshSignCertCmd.Flags().StringVarP(&sshKeyID, "key-id", "I", "", "Certificate key ID, logged by sshd")
+	sshSignCertCmd.Flags().StringSliceVarP(&sshPrincipals, "principals", "n", []string{}, "User names or host names of the certificate")
+	sshSignCertCmd.Flags().BoolVarP(&sshAllowAnyPrincipal, "allow-any-principal", "", false, "Issue a certificate without principals, valid for ANY user or host")
+	sshSignCertCmd.Flags().StringVarP(&sshValidity, "validity", "", "24h", "Validity of the certificate")
+	sshSignCertCmd.Flags().StringArrayVarP(&sshExtensions, "extension", "", []string{}, "Extension 'name[=value]' (repeatable), replaces the default user extensions, '' for none (default permit-X11-forwarding, permit-agent-forwarding, permit-port-forwarding, permit-pty, permit-user-rc for user certificates)")
+	sshSignCertCmd.Flags().StringArrayVarP(&sshCriticalOptions, "critical-option", "", []string{}, "Critical option 'name=value' (repeatable, e.g. 'source-address=10.0.0.0/8')")
+	sshSignCertCmd.Flags().StringVarP(&certOut, "out", "o", "", "Output file for the certificate (default stdout)")
+
+	sshPubKeyCmd.Flags().StringVarP(&transitKey, "transit-key", "t", "", "The name of the transit key of the SSH CA")
+	sshPubKeyCmd.Flags().StringVarP(&transitMount, "mount", "", "transit", "Mount path of transit backend")
+	sshPubKeyCmd.Flags().IntVarP(&keyVersion, "version", "", 0, "Version of the transit key, or 0 for latest (default 0)")
+	sshPubKeyCmd.Flags().StringVarP(&certOut, "out", "o", "", "Output file for the CA public key (default stdout)")
+
+	// required flags
+	//nolint
+	sshSignCertCmd.MarkFlagRequired("transit-key")
+	//nolint
+	sshSignCertCmd.MarkFlagRequired("public-key")
+	//nolint
+	sshSignCertCmd.MarkFlagRequired("key-id")
+	//nolint
+	sshPubKeyCmd.MarkFlagRequired("transit-key")
+
+	sshSignCertCmd.MarkFlagsMutuallyExclusive("principals", "allow-any-principal")
+
+}
+
+var sshCmd = &cobra.Command{
+	Use:   "ssh",
+	Short: "Commands for SSH CA with transit keys",
+	Run: func(cmd *cobra.Command, args []string) {
+
+		// command does nothing
+		err := cmd.Help()
+		if err != nil {
+			log.Fatal(err)
+		}
+		os.Exit(1)
+	},
+}
+
+var sshSignCertCmd = &cobra.Command{
+	Use:   "sign-cert",
+	Short: "Issue an SSH certificate signed with a CA private key in transit backend",
+	Long:  "Issue an SSH user or host certificate for a public key, signed with a CA private key in transit backend",
+	Run:   sshSignCertRun,
+
+	Example: `
+   # user certificate
+   hc-vault-util transit ssh sign-cert --transit-key "ssh-ca" --public-key id_ed25519.pub --key-id alice --principals alice,admin --validity 8h --out id_ed25519-cert.pub
+
+   # host certificate
+   hc-vault-util transit ssh sign-cert --transit-key "ssh-host-ca" --type host --public-key /etc/ssh/ssh_host_ed25519_key.pub --key-id bastion --principals bastion.example.com --validity 720h
+
+   # restricted user certificate
+   hc-vault-util transit ssh sign-cert --transit-key "ssh-ca" --public-key id_ed25519.pub --key-id backup --principals backup --extension "" --critical-option "force-command=/usr/bin/backup"
+
+Mandatory Environment Variables:
+- VAULT_ADDR: Address of the vault server
+- VAULT_TOKEN: Vault authentication token. With permission to read 'transit/keys/[KEY-NAME]' and write 'transit/sign/[KEY-NAME]'.
+
+Optional Environment Variables:
+- VAULT_CACERT: Path to a PEM encoded CA file to verify TLS on the VAULT_ADDR.
+- VAULT_CAPATH: Path to a directory of PEM encoded CA files to verify TLS on the VAULT_ADDR.
+- VAULT_SKIP_VERIFY: To disable TLS verification completely.
+`,
+}
+
+var sshPubKeyCmd = &cobra.Command{
+	Use:   "pubkey",
+	Short: "Print the SSH CA public key of a transit key",
+	Long:  "Print the SSH CA public key of a transit key in authorized_keys format (e.g. for sshd 'TrustedUserCAKeys', or '@cert-authority' in known_hosts)",
+	Run:   sshPubKeyRun,
+
+	Example: `
+   hc-vault-util transit ssh pubkey --transit-key "ssh-ca" --out /etc/ssh/trusted_user_ca_keys
+
+Mandatory Environment Variables:
+- VAULT_ADDR: Address of the vault server
+- VAULT_TOKEN: Vault authentication token. With permission to read 'transit/keys/[KEY-NAME]'.
+
+Optional Environment Variables:
+- VAULT_CACERT: Path to a PEM encoded CA file to verify TLS on the VAULT_ADDR.
+- VAULT_CAPATH: Path to a directory of PEM encoded CA files to verify TLS on the VAULT_ADDR.
+- VAULT_SKIP_VERIFY: To disable TLS verification completely.
+`,
+}
+
+// sshSignCertRun cobra server handler
+func sshSignCertRun(cmd *cobra.Command, args []string) {
+
+	logger := logger.GenLogger(Debug, noColor)
+
+	if len(sshPrincipals) == 0 && !sshAllowAnyPrincipal {
+		logger.Error("one of --principals or --allow-any-principal is required")
+		os.Exit(1)
+	}
+
+	validity, err := time.ParseDuration(sshValidity)
+	if err != nil || validity <= 0 {
+		logger.Error("Invalid validity", "validity", sshValidity, "error", err)
+		os.Exit(1)
+	}
+
+	opts := transit.SSHCertOptions{
+		CertType:          sshCertType,
+		KeyID:             sshKeyID,
+		Principals:        sshPrincipals,
+		AllowAnyPrincipal: sshAllowAnyPrincipal,
+		Validity:          validity,
+	}
+
+	// keep default user extensions if not set
+	if cmd.Flags().Changed("extension") {
+		opts.Extensions, err = parseSSHOptions(sshExtensions, false)
+		if err != nil {
+			logger.Error("Invalid extension", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	opts.CriticalOptions, err = parseSSHOptions(sshCriticalOptions, true)
+	if err != nil {
+		logger.Error("Invalid critical option", "error", err)
+		os.Exit(1)
+	}
+
+	transitClient, err := transit.NewTransitClient(logger)
+	if err != nil {
+		logger.Error("Error creating transit client", "error", err)
+		os.Exit(1)
+	}
+
+	// set key properties
+	transitClient.SetKeyProperties(transitMount, transitKey)
+
+	err = transitClient.SSHSignCert(sshPublicKey, keyVersion, opts, certOut)
+	if err != nil {
+		logger.Error("Error issuing SSH certificate", "error", err)
+		os.Exit(1)
+	}
+
+}
+
+// sshPubKeyRun cobra server handler
+func sshPubKeyRun(cmd *cobra.Command, args []string) {
+
+	logger := logger.GenLogger(Debug, noColor)
+
+	transitClient, err := transit.NewTransitClient(logger)
+	if err != nil {
+		logger.Error("Error creating transit client", "error", err)
+		os.Exit(1)
+	}
+
+	// set key properties
+	transitClient.SetKeyProperties(transitMount, transitKey)
+
+	err = transitClient.SSHPublicKey(keyVersion, certOut)
+	if err != nil {
+		logger.Error("Error exporting SSH CA public key", "error", err)
+		os.Exit(1)
+	}
+
+}
+
+// parseSSHOptions parses 'name[=value]' certificate options, the value is
+// required if requireValue. Empty names are skipped.
+func parseSSHOptions(list []string, requireValue bool) (map[string]string, error) {
+
+	options := map[string]string{}
+	for _, o := range list {
+		if o == "" {
+			continue
+		}
+
+		name, value, found := strings.Cut(o, "=")
+		if name == "" || (requireValue && !found) {
+			return nil, fmt.Errorf("invalid option '%s', expecting 'name=value'", o)
+		}
+		options[name] = value
+	}
+
+	return options, nil
+}
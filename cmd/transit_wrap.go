@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/logger"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit"
+)
+
+var wrappingKeyFile string
+var wrapOut string
+
+func init() {
+	// bind to transit command
+	transitCmd.AddCommand(wrapCmd)
+	// add flags to sub command
+	wrapCmd.Flags().StringVarP(&wrappingKeyFile, "wrapping-key", "w", "", "The PEM encoded transit wrapping key (from 'transit wrapping-key export')")
+	wrapCmd.Flags().StringVarP(&privKey, "pkcs8-pem-key", "k", "", "The private key to wrap (PEM encoded PKCS8, encrypted PKCS8, PKCS1 or SEC1)")
+	wrapCmd.Flags().StringVarP(&passphraseEnv, "passphrase-env", "", "", "Name of the env variable holding the passphrase of an encrypted private key")
+	wrapCmd.Flags().StringVarP(&passphraseFile, "passphrase-file", "", "", "Path to a file holding the passphrase of an encrypted private key")
+	wrapCmd.Flags().StringVarP(&symmetricKey, "symmetric-key", "", "", "The symmetric or hmac key material to wrap")
+	wrapCmd.Flags().StringVarP(&symmetricKeyType, "key-type", "", "", "Transit type of the symmetric key, one of aes128-gcm96, aes256-gcm96, chacha20-poly1305, hmac")
+	wrapCmd.Flags().StringVarP(&symmetricKeyEncoding, "key-encoding", "", "raw", "Encoding of the symmetric key file, one of raw, hex, base64")
+	wrapCmd.Flags().StringVarP(&importHashFunction, "hash-function", "", "SHA256", "Hash function used for RSA-OAEP wrapping, one of SHA1, SHA224, SHA256, SHA384, SHA512")
+	wrapCmd.Flags().StringVarP(&wrapOut, "out", "o", "", "Output file for the base64 ciphertext (default stdout)")
+
+	// required flags
+	//nolint
+	wrapCmd.MarkFlagRequired("wrapping-key")
+
+	wrapCmd.MarkFlagsMutuallyExclusive("passphrase-env", "passphrase-file")
+	wrapCmd.MarkFlagsMutuallyExclusive("pkcs8-pem-key", "symmetric-key")
+	wrapCmd.MarkFlagsRequiredTogether("symmetric-key", "key-type")
+
+}
+
+var wrapCmd = &cobra.Command{
+	Use:   "wrap",
+	Short: "Wraps a key offline for transit import",
+	Long:  "Wraps a private or symmetric key offline with the transit wrapping key, to import later with 'transit import --ciphertext-file'",
+	Run:   wrapRun,
+
+	Example: `
+   # on a connected machine
+   hc-vault-util transit wrapping-key export --out wrapping_key.pem
+
+   # on the air-gapped machine
+   hc-vault-util transit wrap --wrapping-key wrapping_key.pem --pkcs8-pem-key ./key.pem --out key.wrapped
+
+   # back on a connected machine, with the key type logged by 'transit wrap'
+   hc-vault-util transit import --ciphertext-file key.wrapped --key-type rsa-2048 --transit-key rsa-key
+
+NOTE: the same --hash-function must be used for 'transit wrap' and 'transit import'.
+
+No Vault Environment Variables are required.
+`,
+}
+
+// wrapRun cobra server handler
+func wrapRun(cmd *cobra.Command, args []string) {
+
+	logger := logger.GenLogger(Debug, noColor)
+
+	if privKey == "" && symmetricKey == "" {
+		logger.Error("one of --pkcs8-pem-key or --symmetric-key is required")
+		os.Exit(1)
+	}
+
+	wrappingKey, err := transit.LoadWrappingKey(wrappingKeyFile)
+	if err != nil {
+		logger.Error("Error loading wrapping key", "error", err)
+		os.Exit(1)
+	}
+
+	transitClient := transit.NewOfflineTransitClient(logger)
+	transitClient.SetWrappingKey(wrappingKey)
+	transitClient.SetImportOptions(transit.ImportOptions{
+		HashFunction: importHashFunction,
+	})
+
+	keyType := symmetricKeyType
+	if symmetricKey != "" {
+		err = transitClient.WrapSymmetricKey(symmetricKey, symmetricKeyType, symmetricKeyEncoding, wrapOut)
+	} else {
+		passphrase := transit.NewPassphraseSource(passphraseEnv, passphraseFile)
+		keyType, err = transitClient.WrapPrivateKey(privKey, passphrase, wrapOut)
+	}
+	if err != nil {
+		logger.Error("Error wrapping key", "error", err)
+		os.Exit(1)
+	}
+
+	logger.Info("Wrapping successful", "type", keyType, "hash_function", importHashFunction)
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/logger"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit"
+)
+
+var wrappingKeyOut string
+
+func init() {
+	// bind to transit command
+	transitCmd.AddCommand(wrappingKeyCmd)
+	wrappingKeyCmd.AddCommand(wrappingKeyExportCmd)
+
+	// add flags to sub command
+	wrappingKeyExportCmd.Flags().StringVarP(&transitMount, "mount", "", "transit", "Mount path of transit backend")
+	wrappingKeyExportCmd.Flags().StringVarP(&wrappingKeyOut, "out", "o", "", "Output file for the PEM encoded wrapping key (default stdout)")
+
+}
+
+var wrappingKeyCmd = &cobra.Command{
+	Use:   "wrapping-key",
+	Short: "Commands for transit wrapping key",
+	Run: func(cmd *cobra.Command, args []string) {
+
+		// command does nothing
+		err := cmd.Help()
+		if err != nil {
+			log.Fatal(err)
+		}
+		os.Exit(1)
+	},
+}
+
+var wrappingKeyExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the transit wrapping key",
+	Long:  "Export the PEM encoded RSA public wrapping key of transit backend, for offline key wrapping with 'transit wrap'",
+	Run:   wrappingKeyExportRun,
+
+	Example: `
+   hc-vault-util transit wrapping-key export --out wrapping_key.pem
+
+Mandatory Environment Variables:
+- VAULT_ADDR: Address of the vault server 
+- VAULT_TOKEN: Vault authentication token. With permission to read transit/wrapping_key.
+
+Optional Environment Variables:
+- VAULT_CACERT: Path to a PEM encoded CA file to verify TLS on the VAULT_ADDR.
+- VAULT_CAPATH: Path to a directory of PEM encoded CA files to verify TLS on the VAULT_ADDR.
+- VAULT_SKIP_VERIFY: To disable TLS verification completely.
+`,
+}
+
+// wrappingKeyExportRun cobra server handler
+func wrappingKeyExportRun(cmd *cobra.Command, args []string) {
+
+	logger := logger.GenLogger(Debug, noColor)
+
+	transitClient, err := transit.NewTransitClient(logger)
+	if err != nil {
+		logger.Error("Error creating transit client", "error", err)
+		os.Exit(1)
+	}
+
+	// set key properties
+	transitClient.SetKeyProperties(transitMount, "")
+
+	err = transitClient.ExportWrappingKey(wrappingKeyOut)
+	if err != nil {
+		logger.Error("Error exporting wrapping key", "error", err)
+		os.Exit(1)
+	}
+}
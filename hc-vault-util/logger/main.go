@@ -55,8 +55,8 @@ func GetZapLogger(Debug bool, noColor bool) *zap.Logger {
 	// using console encoder since CLI tool
 	consoleEncoder := zapcore.NewConsoleEncoder(zapConfig)
 
-	// default writer for logger
-	consoleDebugging := zapcore.Lock(os.Stdout)
+	// default writer for logger, stderr so stdout only has command output
+	consoleDebugging := zapcore.Lock(os.Stderr)
 	consoleErrors := zapcore.Lock(os.Stderr)
 	if Debug {
 		// set log level to writer
@@ -0,0 +1,202 @@
+package transit
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"os"
+
+	"github.com/cloudflare/cfssl/helpers"
+	"github.com/digitorus/pkcs7"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/key"
+)
+
+// CMS digest algorithms
+var cmsDigestAlgorithms = map[crypto.Hash]asn1.ObjectIdentifier{
+	crypto.SHA256: pkcs7.OIDDigestAlgorithmSHA256,
+	crypto.SHA384: pkcs7.OIDDigestAlgorithmSHA384,
+	crypto.SHA512: pkcs7.OIDDigestAlgorithmSHA512,
+}
+
+// CMSOptions are the options of a CMS SignedData signed with a transit key
+type CMSOptions struct {
+	// PEM intermediate certificates of the signer certificate to include
+	ChainFile string
+	// transit hash_algorithm of the digest (e.g. 'sha2-256')
+	HashAlg string
+	// do not include the content
+	Detached bool
+	// PEM output instead of DER
+	PEM bool
+}
+
+// CMSSign creates the CMS (PKCS#7) SignedData of file (or stdin), with the signed
+// attributes content type, message digest and signing time, and the signer
+// certificate certFile whose private key is version keyVersion (or latest if 0)
+// of the transit key, and writes it to outFile (or stdout)
+func (t *TransitClient) CMSSign(file, certFile string, keyVersion int, opts CMSOptions, outFile string) error {
+
+	content, err := readInput(file)
+	if err != nil {
+		t.logger.Error("Error reading content", "error", err)
+		return err
+	}
+
+	certs, err := loadCertificates(certFile)
+	if err != nil {
+		t.logger.Error("Error loading signer certificate", "error", err)
+		return err
+	}
+	cert := certs[0]
+
+	var chain []*x509.Certificate
+	if opts.ChainFile != "" {
+		chain, err = loadCertificates(opts.ChainFile)
+		if err != nil {
+			t.logger.Error("Error loading certificate chain", "error", err)
+			return err
+		}
+	}
+
+	signer, err := t.newTransitSigner(keyVersion)
+	if err != nil {
+		return err
+	}
+
+	err = checkCertificateMatchesKey(cert, signer)
+	if err != nil {
+		t.logger.Error("Error validating signer certificate", "error", err)
+		return err
+	}
+
+	hash, err := key.VaultHashToCryptoHash(opts.HashAlg)
+	if err != nil {
+		return err
+	}
+	// RFC 8419 section 3.1: Ed25519 requires SHA-512
+	if signer.Key.Type == "ed25519" && hash != crypto.SHA512 {
+		t.logger.Debug("using sha2-512 digest for ed25519", "hash", opts.HashAlg)
+		hash = crypto.SHA512
+	}
+	digestOID, ok := cmsDigestAlgorithms[hash]
+	if !ok {
+		return fmt.Errorf("unsupported CMS hash algorithm '%s'", opts.HashAlg)
+	}
+
+	sd, err := pkcs7.NewSignedData(content)
+	if err != nil {
+		return err
+	}
+	sd.SetDigestAlgorithm(digestOID)
+
+	// signature of the signed attributes by transit
+	err = sd.AddSignerChain(cert, signer, chain, pkcs7.SignerInfoConfig{})
+	if err != nil {
+		t.logger.Error("Error signing with transit", "error", err)
+		return err
+	}
+
+	if opts.Detached {
+		sd.Detach()
+	}
+
+	der, err := sd.Finish()
+	if err != nil {
+		return err
+	}
+
+	t.logger.Info("CMS SignedData", "signer", cert.Subject.String(), "detached", opts.Detached, "hash", hash.String())
+
+	if opts.PEM {
+		return writeOutput(outFile, pem.EncodeToMemory(&pem.Block{Type: "CMS", Bytes: der}), 0644)
+	}
+
+	return writeOutput(outFile, der, 0644)
+}
+
+// CMSVerify verifies the PEM or DER CMS (PKCS#7) SignedData envelopeFile, of the
+// content contentFile if detached, with signer certificates chaining to the
+// PEM trust bundle trustBundleFile. The content of an attached SignedData is
+// written to outFile if set.
+func (t *TransitClient) CMSVerify(envelopeFile, contentFile, trustBundleFile, outFile string) error {
+
+	data, err := os.ReadFile(envelopeFile)
+	if err != nil {
+		t.logger.Error("Error reading CMS SignedData", "error", err)
+		return err
+	}
+
+	// PEM or DER
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+
+	p7, err := pkcs7.Parse(data)
+	if err != nil {
+		t.logger.Error("Error parsing CMS SignedData", "error", err)
+		return err
+	}
+
+	if len(p7.Content) == 0 {
+		if contentFile == "" {
+			return fmt.Errorf("detached CMS SignedData requires the signed content")
+		}
+
+		p7.Content, err = os.ReadFile(contentFile)
+		if err != nil {
+			t.logger.Error("Error reading content", "error", err)
+			return err
+		}
+	} else if contentFile != "" {
+		return fmt.Errorf("CMS SignedData already includes the signed content")
+	}
+
+	bundle, err := os.ReadFile(trustBundleFile)
+	if err != nil {
+		t.logger.Error("Error reading trust bundle", "error", err)
+		return err
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(bundle) {
+		return fmt.Errorf("no PEM certificate in trust bundle '%s'", trustBundleFile)
+	}
+
+	err = p7.VerifyWithChain(roots)
+	if err != nil {
+		return err
+	}
+
+	if signerCert := p7.GetOnlySigner(); signerCert != nil {
+		t.logger.Info("Valid CMS signature", "signer", signerCert.Subject.String())
+	} else {
+		t.logger.Info("Valid CMS signatures", "signers", len(p7.Signers))
+	}
+
+	if outFile != "" && contentFile == "" {
+		return writeOutput(outFile, p7.Content, 0644)
+	}
+
+	return nil
+}
+
+// loadCertificates returns the PEM certificates of file
+func loadCertificates(file string) ([]*x509.Certificate, error) {
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	certs, err := helpers.ParseCertificatesPEM(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no PEM certificate in '%s'", file)
+	}
+
+	return certs, nil
+}
@@ -0,0 +1,165 @@
+package transit
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/internal/transittest"
+)
+
+// newTestSignerCert returns the PEM files of a CA certificate and of the
+// certificate it issued for version 1 of the fake transit key k
+func newTestSignerCert(t *testing.T, k *transittest.Key) (string, string) {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caTpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTpl, caTpl, caKey.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Transit Signer"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, caCert, k.Versions[0].Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caFile := writeTestFile(t, "ca.pem", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})))
+	certFile := writeTestFile(t, "signer.pem", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})))
+
+	return caFile, certFile
+}
+
+func TestCMSRoundTrip(t *testing.T) {
+
+	content := "signed content\n"
+
+	tests := []struct {
+		keyType  string
+		hashAlg  string
+		detached bool
+		pem      bool
+	}{
+		{keyType: "rsa-2048", hashAlg: "sha2-256"},
+		{keyType: "ecdsa-p256", hashAlg: "sha2-256", pem: true},
+		{keyType: "ecdsa-p384", hashAlg: "sha2-384"},
+		// digest forced to sha2-512
+		{keyType: "ed25519", hashAlg: "sha2-256"},
+		{keyType: "rsa-2048", hashAlg: "sha2-512", detached: true},
+		{keyType: "ecdsa-p256", hashAlg: "sha2-256", detached: true, pem: true},
+	}
+
+	for _, tc := range tests {
+		name := tc.keyType + "-" + tc.hashAlg
+		if tc.detached {
+			name += "-detached"
+		}
+
+		t.Run(name, func(t *testing.T) {
+
+			k := transittest.GenerateKey(t, tc.keyType, 1)
+			client := newTestTransitClient(t, "cms", k)
+			caFile, certFile := newTestSignerCert(t, k)
+			contentFile := writeTestFile(t, "content.txt", content)
+
+			dir := t.TempDir()
+			envelope := filepath.Join(dir, "content.p7s")
+			err := client.CMSSign(contentFile, certFile, 0, CMSOptions{HashAlg: tc.hashAlg, Detached: tc.detached, PEM: tc.pem}, envelope)
+			if err != nil {
+				t.Fatalf("CMSSign: %v", err)
+			}
+			if k.SignCount != 1 {
+				t.Errorf("transit sign called %d times, want 1", k.SignCount)
+			}
+
+			verifyContent := ""
+			if tc.detached {
+				verifyContent = contentFile
+
+				err = client.CMSVerify(envelope, "", caFile, "")
+				if err == nil {
+					t.Error("detached CMS SignedData verified without its content")
+				}
+
+				tampered := writeTestFile(t, "tampered.txt", content+"x")
+				err = client.CMSVerify(envelope, tampered, caFile, "")
+				if err == nil {
+					t.Error("detached CMS SignedData verified with tampered content")
+				}
+			}
+
+			out := filepath.Join(dir, "content.out")
+			err = client.CMSVerify(envelope, verifyContent, caFile, out)
+			if err != nil {
+				t.Fatalf("CMSVerify: %v", err)
+			}
+
+			got, err := os.ReadFile(out)
+			if tc.detached {
+				if !os.IsNotExist(err) {
+					t.Errorf("content of detached CMS SignedData written: %v", err)
+				}
+			} else if err != nil || string(got) != content {
+				t.Errorf("content = %q (%v), want %q", got, err, content)
+			}
+
+			// signer certificate not chaining to the trust bundle
+			otherCA, _ := newTestSignerCert(t, k)
+			err = client.CMSVerify(envelope, verifyContent, otherCA, "")
+			if err == nil {
+				t.Error("CMS SignedData verified with an untrusted CA")
+			}
+		})
+	}
+}
+
+func TestCMSSignCertificateKeyMismatch(t *testing.T) {
+
+	k := transittest.GenerateKey(t, "ecdsa-p256", 1)
+	client := newTestTransitClient(t, "cms", k)
+
+	other := transittest.GenerateKey(t, "ecdsa-p256", 1)
+	_, certFile := newTestSignerCert(t, other)
+	contentFile := writeTestFile(t, "content.txt", "signed content\n")
+
+	err := client.CMSSign(contentFile, certFile, 0, CMSOptions{HashAlg: "sha2-256"}, filepath.Join(t.TempDir(), "content.p7s"))
+	if err == nil {
+		t.Fatal("CMSSign with a certificate of another key succeeded")
+	}
+	if k.SignCount != 0 {
+		t.Error("signed with transit despite the certificate mismatch")
+	}
+}
@@ -0,0 +1,130 @@
+package transit
+
+import (
+	"crypto"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+
+	"github.com/cloudflare/cfssl/helpers"
+)
+
+// CosignBundle is the cosign 'sign-blob --bundle' signature bundle
+type CosignBundle struct {
+	Base64Signature string `json:"base64Signature"`
+	// base64 encoded PEM certificate of the signing key
+	Cert string `json:"cert,omitempty"`
+	// transparency log entry, not set as signatures are not uploaded to Rekor
+	RekorBundle json.RawMessage `json:"rekorBundle,omitempty"`
+}
+
+// CosignOptions are the options of a cosign compatible blob signature
+type CosignOptions struct {
+	// output file of the base64 signature (default stdout)
+	SignatureFile string
+	// output file of the signature bundle, no bundle if empty
+	BundleFile string
+	// optional PEM certificate of the transit key, included in the bundle
+	CertFile string
+}
+
+// CosignSignBlob signs blobFile (or stdin) with version keyVersion (or latest if 0)
+// of the transit key, as 'cosign sign-blob' with a private key: SHA-256 with
+// ECDSA or RSA PKCS#1 v1.5, ed25519 over the whole blob. The base64 signature
+// is written to opts.SignatureFile (or stdout), and the bundle to opts.BundleFile
+func (t *TransitClient) CosignSignBlob(blobFile string, keyVersion int, opts CosignOptions) error {
+
+	// do not mix the signature with the bundle on stdout
+	if opts.BundleFile == "-" && (opts.SignatureFile == "" || opts.SignatureFile == "-") {
+		return fmt.Errorf("signature and bundle cannot both be written to stdout")
+	}
+
+	signer, err := t.newTransitSigner(keyVersion)
+	if err != nil {
+		return err
+	}
+
+	var certPEM []byte
+	if opts.CertFile != "" {
+		certPEM, err = os.ReadFile(opts.CertFile)
+		if err != nil {
+			t.logger.Error("Error reading certificate", "error", err)
+			return err
+		}
+
+		cert, err := helpers.ParseCertificatePEM(certPEM)
+		if err != nil {
+			t.logger.Error("Error parsing certificate", "error", err)
+			return err
+		}
+
+		err = checkCertificateMatchesKey(cert, signer)
+		if err != nil {
+			return err
+		}
+
+		// only the leaf certificate
+		certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	}
+
+	var sig []byte
+	if signer.Key.Type == "ed25519" {
+		// ed25519 signs the whole blob
+		blob, err := readInput(blobFile)
+		if err != nil {
+			t.logger.Error("Error reading blob", "error", err)
+			return err
+		}
+
+		sig, err = signer.Sign(rand.Reader, blob, crypto.Hash(0))
+		if err != nil {
+			t.logger.Error("Error signing with transit", "error", err)
+			return err
+		}
+
+	} else {
+		// cosign verifies with SHA-256 for every key type
+		digest, err := hashFile(blobFile, "sha2-256")
+		if err != nil {
+			t.logger.Error("Error hashing blob", "error", err)
+			return err
+		}
+
+		// cosign RSA keys use PKCS#1 v1.5
+		signer.SigAlg = "pkcs1v15"
+		sig, err = signer.Sign(rand.Reader, digest, crypto.SHA256)
+		if err != nil {
+			t.logger.Error("Error signing with transit", "error", err)
+			return err
+		}
+	}
+
+	b64Sig := base64.StdEncoding.EncodeToString(sig)
+
+	t.logger.Info("Blob signed", "key", signer.Key.Name, "version", signer.Version)
+
+	if opts.BundleFile != "" {
+		bundle := CosignBundle{
+			Base64Signature: b64Sig,
+		}
+		if certPEM != nil {
+			bundle.Cert = base64.StdEncoding.EncodeToString(certPEM)
+		}
+
+		data, err := json.Marshal(bundle)
+		if err != nil {
+			return err
+		}
+
+		err = writeOutput(opts.BundleFile, data, 0644)
+		if err != nil {
+			t.logger.Error("Error writing bundle", "error", err)
+			return err
+		}
+	}
+
+	return writeOutput(opts.SignatureFile, []byte(b64Sig), 0644)
+}
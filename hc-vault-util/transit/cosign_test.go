@@ -0,0 +1,137 @@
+package transit
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/internal/transittest"
+)
+
+// TestCosignSignBlob verifies blob signatures as 'cosign verify-blob --key' with
+// the public key exported by 'transit pubkey'
+func TestCosignSignBlob(t *testing.T) {
+
+	blob := []byte("release artifact\n")
+
+	for _, keyType := range []string{"rsa-2048", "ecdsa-p256", "ed25519"} {
+		t.Run(keyType, func(t *testing.T) {
+
+			k := transittest.GenerateKey(t, keyType, 1)
+			tc := newTestTransitClient(t, "cosign", k)
+			_, certFile := newTestSignerCert(t, k)
+			blobFile := writeTestFile(t, "blob", string(blob))
+
+			dir := t.TempDir()
+			pubFile := filepath.Join(dir, "cosign.pub")
+			err := tc.ExportPublicKey(0, false, "pem", pubFile)
+			if err != nil {
+				t.Fatalf("ExportPublicKey: %v", err)
+			}
+			pubPEM, err := os.ReadFile(pubFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pub, err := cryptoutils.UnmarshalPEMToPublicKey(pubPEM)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// SHA-256 PKCS#1 v1.5 and ECDSA, pure ed25519, as cosign
+			verifier, err := signature.LoadVerifier(pub, crypto.SHA256)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			opts := CosignOptions{
+				SignatureFile: filepath.Join(dir, "blob.sig"),
+				BundleFile:    filepath.Join(dir, "blob.bundle"),
+				CertFile:      certFile,
+			}
+			err = tc.CosignSignBlob(blobFile, 0, opts)
+			if err != nil {
+				t.Fatalf("CosignSignBlob: %v", err)
+			}
+
+			b64Sig, err := os.ReadFile(opts.SignatureFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sig, err := base64.StdEncoding.DecodeString(string(b64Sig))
+			if err != nil {
+				t.Fatalf("invalid base64 signature: %v", err)
+			}
+
+			err = verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(blob))
+			if err != nil {
+				t.Errorf("VerifySignature: %v", err)
+			}
+			err = verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(append(blob, 'x')))
+			if err == nil {
+				t.Error("signature of tampered blob verified")
+			}
+
+			data, err := os.ReadFile(opts.BundleFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var bundle CosignBundle
+			err = json.Unmarshal(data, &bundle)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bundle.Base64Signature != string(b64Sig) {
+				t.Errorf("bundle signature %q, want %q", bundle.Base64Signature, b64Sig)
+			}
+
+			// the bundle certificate verifies the signature
+			certPEM, err := base64.StdEncoding.DecodeString(bundle.Cert)
+			if err != nil {
+				t.Fatalf("invalid base64 bundle certificate: %v", err)
+			}
+			certs, err := cryptoutils.UnmarshalCertificatesFromPEM(certPEM)
+			if err != nil || len(certs) != 1 {
+				t.Fatalf("bundle certificates %d: %v", len(certs), err)
+			}
+			certVerifier, err := signature.LoadVerifier(certs[0].PublicKey, crypto.SHA256)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = certVerifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(blob))
+			if err != nil {
+				t.Errorf("VerifySignature with the bundle certificate: %v", err)
+			}
+		})
+	}
+}
+
+func TestCosignSignBlobErrors(t *testing.T) {
+
+	k := transittest.GenerateKey(t, "ecdsa-p256", 1)
+	tc := newTestTransitClient(t, "cosign", k)
+	blobFile := writeTestFile(t, "blob", "release artifact\n")
+
+	err := tc.CosignSignBlob(blobFile, 0, CosignOptions{BundleFile: "-"})
+	if err == nil || !strings.Contains(err.Error(), "stdout") {
+		t.Errorf("CosignSignBlob error = %v, want signature and bundle on stdout", err)
+	}
+
+	other := transittest.GenerateKey(t, "ecdsa-p256", 1)
+	_, certFile := newTestSignerCert(t, other)
+	dir := t.TempDir()
+	err = tc.CosignSignBlob(blobFile, 0, CosignOptions{SignatureFile: filepath.Join(dir, "blob.sig"), CertFile: certFile})
+	if err == nil {
+		t.Error("CosignSignBlob with a certificate of another key succeeded")
+	}
+
+	if k.SignCount != 0 {
+		t.Errorf("transit sign called %d times, want 0", k.SignCount)
+	}
+}
@@ -0,0 +1,69 @@
+package transit
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"time"
+)
+
+// CRLOptions are the options of a CRL signed with a transit key
+type CRLOptions struct {
+	// CRL number, must increase for each CRL of the CA
+	Number *big.Int
+	// validity period from now
+	NextUpdate time.Duration
+}
+
+// GenCRL generates a CRL of the revoked certificates from revokedFile, signed by
+// the CA certificate caCertFile whose private key is version keyVersion (or latest
+// if 0) of the transit key, and writes it PEM encoded to outFile (or stdout)
+func (t *TransitClient) GenCRL(caCertFile string, keyVersion int, revokedFile string, opts CRLOptions, outFile string) error {
+
+	revoked, err := LoadRevocationList(revokedFile)
+	if err != nil {
+		t.logger.Error("Error loading revocation list", "error", err)
+		return err
+	}
+
+	caCert, signer, err := t.loadCASigner(caCertFile, keyVersion)
+	if err != nil {
+		return err
+	}
+
+	der, err := CreateCRL(caCert, signer, revoked, opts)
+	if err != nil {
+		return err
+	}
+
+	t.logger.Info("PEM encoded CRL", "issuer", caCert.Subject.String(), "number", opts.Number.String(), "revoked", len(revoked.Revoked))
+
+	return writeOutput(outFile, pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}), 0644)
+}
+
+// CreateCRL returns the DER encoded CRL of the revoked certificates,
+// issued by caCert and signed by signer
+func CreateCRL(caCert *x509.Certificate, signer crypto.Signer, revoked *RevocationList, opts CRLOptions) ([]byte, error) {
+
+	entries := make([]x509.RevocationListEntry, 0, len(revoked.Revoked))
+	for _, r := range revoked.Revoked {
+		// reason code extension omitted for 'unspecified' (RFC 5280 section 5.3.1)
+		entries = append(entries, x509.RevocationListEntry{
+			SerialNumber:   r.serialNumber,
+			RevocationTime: r.RevokedAt.UTC(),
+			ReasonCode:     r.reasonCode,
+		})
+	}
+
+	now := time.Now()
+	tpl := &x509.RevocationList{
+		Number:                    opts.Number,
+		ThisUpdate:                now,
+		NextUpdate:                now.Add(opts.NextUpdate),
+		RevokedCertificateEntries: entries,
+	}
+
+	return x509.CreateRevocationList(rand.Reader, tpl, caCert, signer)
+}
@@ -0,0 +1,154 @@
+package transit
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/internal/transittest"
+	"golang.org/x/crypto/ocsp"
+)
+
+const testRevocationList = `
+revoked:
+  - serial: "0x1f3a9c"
+    revoked_at: 2024-01-31T12:00:00Z
+    reason: keyCompromise
+  - serial: "1f:3a:9d"
+    revoked_at: 2024-02-01T08:30:00Z
+issued:
+  - "0x2b4d1e"
+`
+
+// newTestCA returns the PEM file and the self-signed CA certificate of
+// version (1 based) of the fake transit key k
+func newTestCA(t *testing.T, k *transittest.Key, version int) (string, *x509.Certificate) {
+	t.Helper()
+
+	priv := k.Versions[version-1]
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, priv.Public(), priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caCert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return writeTestFile(t, "ca.pem", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))), caCert
+}
+
+// writeTestFile writes data to the file name of a test temp directory
+func writeTestFile(t *testing.T, name, data string) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(file, []byte(data), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+func TestGenCRL(t *testing.T) {
+
+	revokedFile := writeTestFile(t, "revoked.yaml", testRevocationList)
+
+	for _, keyType := range []string{"rsa-2048", "ecdsa-p256", "ed25519"} {
+		t.Run(keyType, func(t *testing.T) {
+
+			k := transittest.GenerateKey(t, keyType, 2)
+			caFile, caCert := newTestCA(t, k, 2)
+			tc := newTestTransitClient(t, "ca", k)
+
+			opts := CRLOptions{Number: big.NewInt(42), NextUpdate: 168 * time.Hour}
+			out := filepath.Join(t.TempDir(), "ca.crl")
+			err := tc.GenCRL(caFile, 0, revokedFile, opts, out)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if k.SignCount != 1 || k.LastSignVersion != 2 {
+				t.Errorf("transit signed %d times with version %d, want once with version 2", k.SignCount, k.LastSignVersion)
+			}
+
+			data, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			block, _ := pem.Decode(data)
+			if block == nil || block.Type != "X509 CRL" {
+				t.Fatalf("output is not a PEM CRL: %s", data)
+			}
+
+			crl, err := x509.ParseRevocationList(block.Bytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = crl.CheckSignatureFrom(caCert)
+			if err != nil {
+				t.Fatalf("CRL signature: %v", err)
+			}
+
+			if crl.Number.Cmp(opts.Number) != 0 {
+				t.Errorf("CRL number = %s, want 42", crl.Number)
+			}
+			if validity := crl.NextUpdate.Sub(crl.ThisUpdate); validity != opts.NextUpdate {
+				t.Errorf("CRL validity = %s, want %s", validity, opts.NextUpdate)
+			}
+
+			want := []x509.RevocationListEntry{
+				{SerialNumber: big.NewInt(0x1f3a9c), RevocationTime: time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC), ReasonCode: ocsp.KeyCompromise},
+				{SerialNumber: big.NewInt(0x1f3a9d), RevocationTime: time.Date(2024, 2, 1, 8, 30, 0, 0, time.UTC), ReasonCode: ocsp.Unspecified},
+			}
+			if len(crl.RevokedCertificateEntries) != len(want) {
+				t.Fatalf("CRL has %d entries, want %d", len(crl.RevokedCertificateEntries), len(want))
+			}
+			for i, e := range crl.RevokedCertificateEntries {
+				if e.SerialNumber.Cmp(want[i].SerialNumber) != 0 || !e.RevocationTime.Equal(want[i].RevocationTime) || e.ReasonCode != want[i].ReasonCode {
+					t.Errorf("entry #%d = %s %s reason %d, want %s %s reason %d", i+1,
+						e.SerialNumber, e.RevocationTime, e.ReasonCode,
+						want[i].SerialNumber, want[i].RevocationTime, want[i].ReasonCode)
+				}
+			}
+		})
+	}
+}
+
+func TestGenCRLCAKeyMismatch(t *testing.T) {
+
+	revokedFile := writeTestFile(t, "revoked.yaml", testRevocationList)
+
+	// CA certificate of the latest version
+	k := transittest.GenerateKey(t, "ecdsa-p256", 2)
+	caFile, _ := newTestCA(t, k, 2)
+	tc := newTestTransitClient(t, "ca", k)
+
+	opts := CRLOptions{Number: big.NewInt(1), NextUpdate: time.Hour}
+	err := tc.GenCRL(caFile, 1, revokedFile, opts, filepath.Join(t.TempDir(), "ca.crl"))
+	if err == nil {
+		t.Fatal("GenCRL with another key version than the CA succeeded, want error")
+	}
+	if k.SignCount != 0 {
+		t.Errorf("transit signed %d times, want no signature", k.SignCount)
+	}
+}
@@ -1,84 +1,333 @@
 package transit
 
 import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/cloudflare/cfssl/csr"
-	"github.com/cloudflare/cfssl/log"
-	"github.com/vdbulcke/hc-vault-util/hc-vault-util/logger"
+	"github.com/cloudflare/cfssl/helpers"
 	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/key"
 )
 
-func (t *TransitClient) GenCSR(cfsslCSRFile string, keyVersion int) error {
-	// read private key file
-	data, err := os.ReadFile(cfsslCSRFile)
+// CSRFormats are the output formats of a CSR
+var CSRFormats = []string{"pem", "der", "json"}
+
+// CSROptions are the signature and output options of a CSR signed with a transit key
+type CSROptions struct {
+	// 'pkcs1v15' or 'pss' for RSA keys, default 'pkcs1v15'
+	SigAlg string
+	// transit hash_algorithm (e.g. 'sha2-384'), default depends on the key type
+	HashAlg string
+
+	// output format, one of CSRFormats, default 'pem'
+	Format string
+	// replace an existing output file
+	Force bool
+	// kv2 secret where to store the CSR and its metadata (disabled if KV2Path is empty)
+	KV2Mount string
+	KV2Path  string
+}
+
+// default hash_algorithm of CSR signatures per key type, as cfssl
+// 'helpers.SignerAlgo' (sha2-256 for other key types)
+var defaultCSRHashAlgorithms = map[string]string{
+	"rsa-3072":   "sha2-384",
+	"rsa-4096":   "sha2-512",
+	"ecdsa-p384": "sha2-384",
+	"ecdsa-p521": "sha2-512",
+}
+
+// x509 signature algorithms per signature_algorithm and hash
+var x509RSASignatureAlgorithms = map[string]map[crypto.Hash]x509.SignatureAlgorithm{
+	"pkcs1v15": {
+		crypto.SHA256: x509.SHA256WithRSA,
+		crypto.SHA384: x509.SHA384WithRSA,
+		crypto.SHA512: x509.SHA512WithRSA,
+	},
+	"pss": {
+		crypto.SHA256: x509.SHA256WithRSAPSS,
+		crypto.SHA384: x509.SHA384WithRSAPSS,
+		crypto.SHA512: x509.SHA512WithRSAPSS,
+	},
+}
+
+var x509ECDSASignatureAlgorithms = map[crypto.Hash]x509.SignatureAlgorithm{
+	crypto.SHA256: x509.ECDSAWithSHA256,
+	crypto.SHA384: x509.ECDSAWithSHA384,
+	crypto.SHA512: x509.ECDSAWithSHA512,
+}
+
+// GenCSR generates the CSR of the cfssl CSR JSON file signed with version
+// keyVersion (or latest if 0) of the transit key, and writes it to outFile (or stdout)
+func (t *TransitClient) GenCSR(cfsslCSRFile string, keyVersion int, opts CSROptions, outFile string) error {
+
+	req, err := t.loadCfsslCSR(cfsslCSRFile)
 	if err != nil {
-		t.logger.Error("Error reading csr config file", "error", err)
 		return err
 	}
 
-	// parse Cfssl CSR JSON format
-	req := &csr.CertificateRequest{}
-	err = json.Unmarshal(data, req)
+	tpl, err := csrTemplate(req)
 	if err != nil {
 		return err
 	}
 
-	// validation
+	return t.genCSR(tpl, "", keyVersion, opts, outFile)
+}
 
-	// get zap logger from hclog properties
-	hasDebug := t.logger.IsDebug()
-	hasNoColor := true
-	zapLog := logger.GetZapLogger(hasDebug, hasNoColor)
-	// quite cfssl logger
-	log.Level = log.LevelCritical
+// GenCSRFromSpec generates the CSR of the CSR specification signed with version
+// keyVersion (or latest if 0) of the transit key, and writes it to outFile (or stdout)
+func (t *TransitClient) GenCSRFromSpec(spec *CSRSpec, keyVersion int, opts CSROptions, outFile string) error {
 
-	// create a new transit key
-	k, err := key.NewVaultTransitKey(t.ctx, zapLog, t.client, t.transitMount, t.keyName)
+	tpl, err := spec.Template()
 	if err != nil {
+		t.logger.Error("Error building CSR template", "error", err)
 		return err
 	}
 
-	// get latest key info
-	// version public keys
-	err = k.SyncKeyInfo()
+	return t.genCSR(tpl, spec.ChallengePassword, keyVersion, opts, outFile)
+}
+
+// genCSR signs the CSR template, with the challenge password attribute if not
+// empty, with the transit key, writes it to the new file outFile (or stdout)
+// and then stores it in kv2 if enabled
+func (t *TransitClient) genCSR(tpl *x509.CertificateRequest, challengePassword string, keyVersion int, opts CSROptions, outFile string) error {
+
+	format := opts.Format
+	if format == "" {
+		format = "pem"
+	}
+	err := checkCSRFormat(format)
 	if err != nil {
 		return err
 	}
 
-	// validate key version
-	if keyVersion > 0 {
-
-		if keyVersion < k.MinVersion || keyVersion > k.Version {
-			t.logger.Error("invalid key version, must be within", "min_version", k.MinVersion, "max_version", k.Version)
-			return fmt.Errorf("invalid key version %d", keyVersion)
+	var challengePasswordAttr []byte
+	if challengePassword != "" {
+		challengePasswordAttr, err = challengePasswordAttribute(challengePassword)
+		if err != nil {
+			return err
 		}
+	}
+
+	signer, err := t.newTransitSigner(keyVersion)
+	if err != nil {
+		return err
+	}
 
-		k.Version = keyVersion
+	sigAlgo, err := x509SignatureAlgorithm(signer.Key.Type, opts)
+	if err != nil {
+		return err
+	}
+	tpl.SignatureAlgorithm = sigAlgo
+
+	// signed by the transit signer, with rsa.PSSOptions for RSA PSS
+	var der []byte
+	if challengePasswordAttr != nil {
+		der, err = createCSRWithAttributes(tpl, [][]byte{challengePasswordAttr}, signer, sigAlgo)
+	} else {
+		der, err = x509.CreateCertificateRequest(rand.Reader, tpl, signer)
+	}
+	if err != nil {
+		t.logger.Error("Error signing CSR", "error", err)
+		return err
 	}
 
-	// set default signing alg pkcs1v15 for RSA
-	vaultSigAlg := "pss"
-	if strings.HasPrefix(k.Type, "rsa-") {
-		vaultSigAlg = "pkcs1v15"
+	// check the transit signature
+	parsed, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return err
 	}
+	err = parsed.CheckSignature()
+	if err != nil {
+		t.logger.Error("Invalid CSR signature", "error", err)
+		return err
+	}
+
+	t.logger.Info("CSR", "subject", parsed.Subject.String(), "version", signer.Version, "signature_algorithm", sigAlgo.String())
 
-	// create new Transit SIgner
-	signer := key.NewTransitSigner(k, vaultSigAlg)
+	data := csrSecretData(t.transitMount, t.keyName, signer.Version, parsed, time.Now())
 
-	// use cfssl to generate the CSR with the transit signer
-	csrPem, err := csr.Generate(signer, req)
+	var out []byte
+	switch format {
+	case "der":
+		out = der
+	case "json":
+		out, err = json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return err
+		}
+		out = append(out, '\n')
+	default:
+		out = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+	}
+
+	// output first, kv2 must not reference a CSR the caller did not get
+	err = createOutput(outFile, out, 0600, opts.Force)
 	if err != nil {
+		t.logger.Error("Error writing CSR", "error", err)
 		return err
 	}
 
-	t.logger.Info("PEM encoded CSR")
+	if opts.KV2Path != "" {
+		err = t.writeKV2Secret(opts.KV2Mount, opts.KV2Path, data)
+		if err != nil {
+			t.logger.Error("Error storing CSR in kv2", "error", err)
+			return err
+		}
+
+		t.logger.Info("CSR stored", "mount", opts.KV2Mount, "path", opts.KV2Path)
+	}
 
-	// print CSR
-	fmt.Println(string(csrPem[:]))
 	return nil
+}
+
+// checkCSRFormat returns an error if format is not one of CSRFormats
+func checkCSRFormat(format string) error {
+
+	for _, f := range CSRFormats {
+		if f == format {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unsupported CSR format '%s', must be one of %s", format, strings.Join(CSRFormats, ", "))
+}
+
+// csrSecretData returns the CSR with its transit key and metadata, as JSON
+// output and kv2 secret data
+func csrSecretData(transitMount, keyName string, keyVersion int, req *x509.CertificateRequest, createdAt time.Time) map[string]interface{} {
+
+	return map[string]interface{}{
+		"csr":                 string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: req.Raw})),
+		"subject":             req.Subject.String(),
+		"signature_algorithm": req.SignatureAlgorithm.String(),
+		"created_at":          createdAt.UTC().Format(time.RFC3339),
+		"transit_mount":       transitMount,
+		"transit_key":         keyName,
+		"transit_key_version": keyVersion,
+	}
+}
+
+// x509SignatureAlgorithm returns the CSR signature algorithm of the transit key
+// type keyType for the signature options
+func x509SignatureAlgorithm(keyType string, opts CSROptions) (x509.SignatureAlgorithm, error) {
+
+	if keyType == "ed25519" {
+		if opts.SigAlg != "" || opts.HashAlg != "" {
+			return x509.UnknownSignatureAlgorithm, fmt.Errorf("ed25519 keys do not support signature algorithm or hash options")
+		}
+		return x509.PureEd25519, nil
+	}
+
+	hashAlg := opts.HashAlg
+	if hashAlg == "" {
+		hashAlg = "sha2-256"
+		if h, ok := defaultCSRHashAlgorithms[keyType]; ok {
+			hashAlg = h
+		}
+	}
+
+	hash, err := key.VaultHashToCryptoHash(hashAlg)
+	if err != nil {
+		return x509.UnknownSignatureAlgorithm, err
+	}
+
+	var sigAlgo x509.SignatureAlgorithm
+	switch {
+	case strings.HasPrefix(keyType, "rsa-"):
+		sigAlg := opts.SigAlg
+		if sigAlg == "" {
+			sigAlg = "pkcs1v15"
+		}
+
+		algos, ok := x509RSASignatureAlgorithms[sigAlg]
+		if !ok {
+			return x509.UnknownSignatureAlgorithm, fmt.Errorf("invalid signature algorithm '%s', must be one of pkcs1v15, pss", sigAlg)
+		}
+		sigAlgo = algos[hash]
+
+	case strings.HasPrefix(keyType, "ecdsa-"):
+		if opts.SigAlg != "" {
+			return x509.UnknownSignatureAlgorithm, fmt.Errorf("signature algorithm '%s' is only supported by RSA keys", opts.SigAlg)
+		}
+		sigAlgo = x509ECDSASignatureAlgorithms[hash]
+
+	default:
+		return x509.UnknownSignatureAlgorithm, fmt.Errorf("unsupported key type '%s' for CSR", keyType)
+	}
+
+	if sigAlgo == x509.UnknownSignatureAlgorithm {
+		return sigAlgo, fmt.Errorf("unsupported CSR hash algorithm '%s', must be one of sha2-256, sha2-384, sha2-512", hashAlg)
+	}
+
+	return sigAlgo, nil
+}
+
+// csrTemplate returns the CSR template of the cfssl CSR, as cfssl 'csr.Generate'
+func csrTemplate(req *csr.CertificateRequest) (*x509.CertificateRequest, error) {
+
+	subj, err := req.Name()
+	if err != nil {
+		return nil, err
+	}
+
+	tpl := &x509.CertificateRequest{
+		Subject: subj,
+	}
+
+	tpl.DNSNames, tpl.IPAddresses, tpl.EmailAddresses, tpl.URIs = parseHosts(req.Hosts)
+
+	if req.CA != nil {
+		pathlen := req.CA.PathLength
+		if pathlen == 0 && !req.CA.PathLenZero {
+			pathlen = -1
+		}
+
+		val, err := asn1.Marshal(csr.BasicConstraints{IsCA: true, MaxPathLen: pathlen})
+		if err != nil {
+			return nil, err
+		}
+
+		tpl.ExtraExtensions = append(tpl.ExtraExtensions, pkix.Extension{
+			Id:       oidExtensionBasicConstraints,
+			Value:    val,
+			Critical: true,
+		})
+	}
+
+	if req.DelegationEnabled {
+		tpl.ExtraExtensions = append(tpl.ExtraExtensions, helpers.DelegationExtension)
+	}
+
+	tpl.ExtraExtensions = append(tpl.ExtraExtensions, req.Extensions...)
+
+	return tpl, nil
+}
+
+// loadCfsslCSR reads the cfssl CSR JSON file
+func (t *TransitClient) loadCfsslCSR(cfsslCSRFile string) (*csr.CertificateRequest, error) {
+	// read csr config file
+	data, err := os.ReadFile(cfsslCSRFile)
+	if err != nil {
+		t.logger.Error("Error reading csr config file", "error", err)
+		return nil, err
+	}
+
+	// parse Cfssl CSR JSON format
+	req := &csr.CertificateRequest{}
+	err = json.Unmarshal(data, req)
+	if err != nil {
+		return nil, err
+	}
 
+	return req, nil
 }
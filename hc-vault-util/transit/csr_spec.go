@@ -0,0 +1,540 @@
+package transit
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/cloudflare/cfssl/csr"
+	"gopkg.in/yaml.v3"
+)
+
+// x509 extension OIDs
+var (
+	oidExtensionKeyUsage         = asn1.ObjectIdentifier{2, 5, 29, 15}
+	oidExtensionBasicConstraints = asn1.ObjectIdentifier{2, 5, 29, 19}
+	oidExtensionExtendedKeyUsage = asn1.ObjectIdentifier{2, 5, 29, 37}
+)
+
+// oidChallengePassword PKCS#9 challenge password CSR attribute (RFC 2985 section 5.4.1)
+var oidChallengePassword = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 7}
+
+// CSR signature algorithm OIDs (RFC 4055, RFC 5758 and RFC 8410)
+var signatureAlgorithmOIDs = map[x509.SignatureAlgorithm]asn1.ObjectIdentifier{
+	x509.SHA256WithRSA:    {1, 2, 840, 113549, 1, 1, 11},
+	x509.SHA384WithRSA:    {1, 2, 840, 113549, 1, 1, 12},
+	x509.SHA512WithRSA:    {1, 2, 840, 113549, 1, 1, 13},
+	x509.SHA256WithRSAPSS: {1, 2, 840, 113549, 1, 1, 10},
+	x509.SHA384WithRSAPSS: {1, 2, 840, 113549, 1, 1, 10},
+	x509.SHA512WithRSAPSS: {1, 2, 840, 113549, 1, 1, 10},
+	x509.ECDSAWithSHA256:  {1, 2, 840, 10045, 4, 3, 2},
+	x509.ECDSAWithSHA384:  {1, 2, 840, 10045, 4, 3, 3},
+	x509.ECDSAWithSHA512:  {1, 2, 840, 10045, 4, 3, 4},
+	x509.PureEd25519:      {1, 3, 101, 112},
+}
+
+// hash algorithm OIDs of RSA PSS parameters
+var hashOIDs = map[crypto.Hash]asn1.ObjectIdentifier{
+	crypto.SHA256: {2, 16, 840, 1, 101, 3, 4, 2, 1},
+	crypto.SHA384: {2, 16, 840, 1, 101, 3, 4, 2, 2},
+	crypto.SHA512: {2, 16, 840, 1, 101, 3, 4, 2, 3},
+}
+
+// oidMGF1 mask generation function of RSA PSS parameters
+var oidMGF1 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 8}
+
+// maxChallengePasswordLength is ub-challengePassword of RFC 2985
+const maxChallengePasswordLength = 255
+
+// extended key usage OIDs of the cfssl extended key usage names
+var extKeyUsageOIDs = map[string]asn1.ObjectIdentifier{
+	"any":              {2, 5, 29, 37, 0},
+	"server auth":      {1, 3, 6, 1, 5, 5, 7, 3, 1},
+	"client auth":      {1, 3, 6, 1, 5, 5, 7, 3, 2},
+	"code signing":     {1, 3, 6, 1, 5, 5, 7, 3, 3},
+	"email protection": {1, 3, 6, 1, 5, 5, 7, 3, 4},
+	"s/mime":           {1, 3, 6, 1, 5, 5, 7, 3, 4},
+	"ipsec end system": {1, 3, 6, 1, 5, 5, 7, 3, 5},
+	"ipsec tunnel":     {1, 3, 6, 1, 5, 5, 7, 3, 6},
+	"ipsec user":       {1, 3, 6, 1, 5, 5, 7, 3, 7},
+	"timestamping":     {1, 3, 6, 1, 5, 5, 7, 3, 8},
+	"ocsp signing":     {1, 3, 6, 1, 5, 5, 7, 3, 9},
+	"microsoft sgc":    {1, 3, 6, 1, 4, 1, 311, 10, 3, 3},
+	"netscape sgc":     {2, 16, 840, 1, 113730, 4, 1},
+}
+
+// CSRSpec is a YAML or JSON CSR specification, with the x509 fields cfssl CSR
+// JSON cannot express
+type CSRSpec struct {
+	Subject CSRSubject `yaml:"subject"`
+
+	// subject alternative names
+	DNSNames       []string `yaml:"dns_names"`
+	IPAddresses    []string `yaml:"ip_addresses"`
+	EmailAddresses []string `yaml:"email_addresses"`
+	URIs           []string `yaml:"uris"`
+
+	// cfssl key usage names (e.g. 'digital signature')
+	KeyUsage []string `yaml:"key_usage"`
+	// cfssl extended key usage names (e.g. 'server auth') or OIDs
+	ExtKeyUsage []string `yaml:"ext_key_usage"`
+
+	BasicConstraints *CSRBasicConstraints `yaml:"basic_constraints"`
+
+	// arbitrary requested extensions
+	Extensions []CSRExtension `yaml:"extensions"`
+
+	// PKCS#9 challenge password attribute, e.g. for SCEP enrollment.
+	// Stored in clear in the CSR.
+	ChallengePassword string `yaml:"challenge_password"`
+}
+
+// CSRSubject is the subject distinguished name of a CSRSpec
+type CSRSubject struct {
+	CommonName         string   `yaml:"common_name"`
+	SerialNumber       string   `yaml:"serial_number"`
+	Country            []string `yaml:"country"`
+	Province           []string `yaml:"province"`
+	Locality           []string `yaml:"locality"`
+	StreetAddress      []string `yaml:"street_address"`
+	PostalCode         []string `yaml:"postal_code"`
+	Organization       []string `yaml:"organization"`
+	OrganizationalUnit []string `yaml:"organizational_unit"`
+
+	// other attributes by OID (e.g. '2.5.4.12' title)
+	ExtraNames []CSRAttribute `yaml:"extra_names"`
+}
+
+// CSRAttribute is a subject attribute by OID
+type CSRAttribute struct {
+	OID   string `yaml:"oid"`
+	Value string `yaml:"value"`
+}
+
+// CSRBasicConstraints is the requested basic constraints extension
+type CSRBasicConstraints struct {
+	IsCA bool `yaml:"is_ca"`
+	// max path length for CA, unlimited if not set
+	MaxPathLen *int `yaml:"max_path_len"`
+}
+
+// CSRExtension is a requested extension by OID
+type CSRExtension struct {
+	OID      string `yaml:"oid"`
+	Critical bool   `yaml:"critical"`
+	// base64 DER encoded extension value
+	Value string `yaml:"value"`
+}
+
+// LoadCSRSpec reads the YAML or JSON CSR specification from file
+func LoadCSRSpec(file string) (*CSRSpec, error) {
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is valid YAML
+	spec := &CSRSpec{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	// reject typos in field names
+	dec.KnownFields(true)
+	err = dec.Decode(spec)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing CSR spec %s: %w", file, err)
+	}
+
+	return spec, nil
+}
+
+// Template returns the x509 CSR template of the specification
+func (s *CSRSpec) Template() (*x509.CertificateRequest, error) {
+
+	subject, err := s.Subject.name()
+	if err != nil {
+		return nil, err
+	}
+
+	tpl := &x509.CertificateRequest{
+		Subject:        subject,
+		DNSNames:       s.DNSNames,
+		EmailAddresses: s.EmailAddresses,
+	}
+
+	for _, v := range s.IPAddresses {
+		ip := net.ParseIP(v)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address '%s'", v)
+		}
+		tpl.IPAddresses = append(tpl.IPAddresses, ip)
+	}
+
+	for _, v := range s.URIs {
+		uri, err := url.Parse(v)
+		if err != nil || uri.Scheme == "" {
+			return nil, fmt.Errorf("invalid URI '%s', expecting an absolute URI", v)
+		}
+		tpl.URIs = append(tpl.URIs, uri)
+	}
+
+	if len(s.KeyUsage) > 0 {
+		ext, err := keyUsageExtension(s.KeyUsage)
+		if err != nil {
+			return nil, err
+		}
+		tpl.ExtraExtensions = append(tpl.ExtraExtensions, ext)
+	}
+
+	if len(s.ExtKeyUsage) > 0 {
+		ext, err := extKeyUsageExtension(s.ExtKeyUsage)
+		if err != nil {
+			return nil, err
+		}
+		tpl.ExtraExtensions = append(tpl.ExtraExtensions, ext)
+	}
+
+	if s.BasicConstraints != nil {
+		ext, err := s.BasicConstraints.extension()
+		if err != nil {
+			return nil, err
+		}
+		tpl.ExtraExtensions = append(tpl.ExtraExtensions, ext)
+	}
+
+	for _, e := range s.Extensions {
+		ext, err := e.extension()
+		if err != nil {
+			return nil, err
+		}
+		tpl.ExtraExtensions = append(tpl.ExtraExtensions, ext)
+	}
+
+	// duplicate extensions are rejected by x509 parsers
+	seen := map[string]bool{}
+	for _, ext := range tpl.ExtraExtensions {
+		if seen[ext.Id.String()] {
+			return nil, fmt.Errorf("duplicate extension %s", ext.Id.String())
+		}
+		seen[ext.Id.String()] = true
+	}
+
+	return tpl, nil
+}
+
+// name returns the pkix name of the subject
+func (s *CSRSubject) name() (pkix.Name, error) {
+
+	name := pkix.Name{
+		CommonName:         s.CommonName,
+		SerialNumber:       s.SerialNumber,
+		Country:            s.Country,
+		Province:           s.Province,
+		Locality:           s.Locality,
+		StreetAddress:      s.StreetAddress,
+		PostalCode:         s.PostalCode,
+		Organization:       s.Organization,
+		OrganizationalUnit: s.OrganizationalUnit,
+	}
+
+	for _, a := range s.ExtraNames {
+		oid, err := csr.OIDFromString(a.OID)
+		if err != nil {
+			return name, fmt.Errorf("invalid subject attribute OID '%s': %w", a.OID, err)
+		}
+		name.ExtraNames = append(name.ExtraNames, pkix.AttributeTypeAndValue{Type: oid, Value: a.Value})
+	}
+
+	return name, nil
+}
+
+// extension returns the basic constraints extension
+func (b *CSRBasicConstraints) extension() (pkix.Extension, error) {
+
+	pathlen := -1
+	if b.MaxPathLen != nil {
+		if !b.IsCA {
+			return pkix.Extension{}, fmt.Errorf("max_path_len requires is_ca")
+		}
+		pathlen = *b.MaxPathLen
+	}
+
+	val, err := asn1.Marshal(csr.BasicConstraints{IsCA: b.IsCA, MaxPathLen: pathlen})
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+
+	return pkix.Extension{Id: oidExtensionBasicConstraints, Critical: true, Value: val}, nil
+}
+
+// extension returns the DER extension
+func (e *CSRExtension) extension() (pkix.Extension, error) {
+
+	oid, err := csr.OIDFromString(e.OID)
+	if err != nil {
+		return pkix.Extension{}, fmt.Errorf("invalid extension OID '%s': %w", e.OID, err)
+	}
+
+	val, err := base64.StdEncoding.DecodeString(e.Value)
+	if err != nil {
+		return pkix.Extension{}, fmt.Errorf("invalid base64 value of extension %s: %w", e.OID, err)
+	}
+
+	// value must be a single DER element
+	var raw asn1.RawValue
+	rest, err := asn1.Unmarshal(val, &raw)
+	if err != nil || len(rest) != 0 {
+		return pkix.Extension{}, fmt.Errorf("value of extension %s is not DER encoded", e.OID)
+	}
+
+	return pkix.Extension{Id: oid, Critical: e.Critical, Value: val}, nil
+}
+
+// keyUsageExtension returns the critical key usage extension of the cfssl key
+// usage names
+func keyUsageExtension(names []string) (pkix.Extension, error) {
+
+	keyUsage, _, err := parseUsages(names, nil)
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+
+	// RFC 5280 KeyUsage bit string, digitalSignature is bit 0
+	var bits [2]byte
+	bitLength := 0
+	for i := 0; i < 9; i++ {
+		if keyUsage&(1<<i) != 0 {
+			bits[i/8] |= 0x80 >> (i % 8)
+			bitLength = i + 1
+		}
+	}
+
+	val, err := asn1.Marshal(asn1.BitString{Bytes: bits[:(bitLength+7)/8], BitLength: bitLength})
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+
+	return pkix.Extension{Id: oidExtensionKeyUsage, Critical: true, Value: val}, nil
+}
+
+// extKeyUsageExtension returns the extended key usage extension of the cfssl
+// extended key usage names or OIDs
+func extKeyUsageExtension(names []string) (pkix.Extension, error) {
+
+	oids := []asn1.ObjectIdentifier{}
+	for _, name := range names {
+		oid, ok := extKeyUsageOIDs[name]
+		if !ok {
+			var err error
+			oid, err = csr.OIDFromString(name)
+			if err != nil {
+				return pkix.Extension{}, fmt.Errorf("unknown extended key usage '%s'", name)
+			}
+		}
+		oids = append(oids, oid)
+	}
+
+	val, err := asn1.Marshal(oids)
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+
+	return pkix.Extension{Id: oidExtensionExtendedKeyUsage, Value: val}, nil
+}
+
+// challengePasswordAttribute returns the DER challenge password attribute, a
+// PrintableString if possible, else a UTF8String
+func challengePasswordAttribute(password string) ([]byte, error) {
+
+	if !utf8.ValidString(password) || utf8.RuneCountInString(password) > maxChallengePasswordLength {
+		return nil, fmt.Errorf("challenge password must be a UTF-8 string of at most %d characters", maxChallengePasswordLength)
+	}
+
+	value, err := asn1.MarshalWithParams(password, "printable")
+	if err != nil {
+		value, err = asn1.MarshalWithParams(password, "utf8")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return asn1.Marshal(struct {
+		Type   asn1.ObjectIdentifier
+		Values []asn1.RawValue `asn1:"set"`
+	}{
+		Type:   oidChallengePassword,
+		Values: []asn1.RawValue{{FullBytes: value}},
+	})
+}
+
+// certificateRequest ASN.1 PKCS#10 CSR (RFC 2986)
+type certificateRequest struct {
+	Raw                asn1.RawContent
+	TBSCSR             tbsCertificateRequest
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	SignatureValue     asn1.BitString
+}
+
+type tbsCertificateRequest struct {
+	Raw           asn1.RawContent
+	Version       int
+	Subject       asn1.RawValue
+	PublicKey     asn1.RawValue
+	RawAttributes []asn1.RawValue `asn1:"tag:0"`
+}
+
+// pssParameters ASN.1 RSASSA-PSS-params (RFC 4055), with the default trailer field
+type pssParameters struct {
+	Hash       pkix.AlgorithmIdentifier `asn1:"explicit,tag:0"`
+	MGF        pkix.AlgorithmIdentifier `asn1:"explicit,tag:1"`
+	SaltLength int                      `asn1:"explicit,tag:2"`
+}
+
+// createCSRWithAttributes returns the DER CSR of tpl with the DER attributes
+// attrs added, signed once by signer with the CSR signature algorithm sigAlgo.
+//
+// x509.CreateCertificateRequest cannot encode attributes other than the
+// extension request: x509 encodes the subject and extension request of tpl
+// with an ephemeral ed25519 key, which is then replaced by the signer public key.
+func createCSRWithAttributes(tpl *x509.CertificateRequest, attrs [][]byte, signer crypto.Signer, sigAlgo x509.SignatureAlgorithm) ([]byte, error) {
+
+	algo, err := signatureAlgorithmIdentifier(sigAlgo)
+	if err != nil {
+		return nil, err
+	}
+
+	_, ephemeral, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeralTpl := *tpl
+	ephemeralTpl.SignatureAlgorithm = x509.PureEd25519
+	der, err := x509.CreateCertificateRequest(rand.Reader, &ephemeralTpl, ephemeral)
+	if err != nil {
+		return nil, err
+	}
+
+	var csr certificateRequest
+	_, err = asn1.Unmarshal(der, &csr)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	tbs := csr.TBSCSR
+	tbs.Raw = nil
+	tbs.PublicKey = asn1.RawValue{FullBytes: publicKey}
+	for _, attr := range attrs {
+		tbs.RawAttributes = append(tbs.RawAttributes, asn1.RawValue{FullBytes: attr})
+	}
+	// DER SET OF is sorted by encoding
+	sort.Slice(tbs.RawAttributes, func(i, j int) bool {
+		return bytes.Compare(tbs.RawAttributes[i].FullBytes, tbs.RawAttributes[j].FullBytes) < 0
+	})
+
+	tbsDER, err := asn1.Marshal(tbs)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, pss := signatureHash(sigAlgo)
+
+	var opts crypto.SignerOpts = hash
+	if pss {
+		opts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: hash}
+	}
+
+	// ed25519 signs the message
+	signed := tbsDER
+	if hash != crypto.Hash(0) {
+		h := hash.New()
+		h.Write(tbsDER)
+		signed = h.Sum(nil)
+	}
+
+	sig, err := signer.Sign(rand.Reader, signed, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(certificateRequest{
+		TBSCSR:             tbsCertificateRequest{Raw: tbsDER},
+		SignatureAlgorithm: algo,
+		SignatureValue:     asn1.BitString{Bytes: sig, BitLength: len(sig) * 8},
+	})
+}
+
+// signatureAlgorithmIdentifier returns the AlgorithmIdentifier of the CSR
+// signature algorithm, encoded as x509: NULL parameters for RSA PKCS#1 v1.5,
+// RSASSA-PSS-params for RSA PSS (RFC 4055) and none for ECDSA and ed25519
+func signatureAlgorithmIdentifier(sigAlgo x509.SignatureAlgorithm) (pkix.AlgorithmIdentifier, error) {
+
+	oid, ok := signatureAlgorithmOIDs[sigAlgo]
+	if !ok {
+		return pkix.AlgorithmIdentifier{}, fmt.Errorf("unsupported CSR signature algorithm %s", sigAlgo)
+	}
+
+	hash, pss := signatureHash(sigAlgo)
+	switch {
+	case pss:
+		hashAlgo := pkix.AlgorithmIdentifier{Algorithm: hashOIDs[hash], Parameters: asn1.NullRawValue}
+		mgfParams, err := asn1.Marshal(hashAlgo)
+		if err != nil {
+			return pkix.AlgorithmIdentifier{}, err
+		}
+
+		params, err := asn1.Marshal(pssParameters{
+			Hash:       hashAlgo,
+			MGF:        pkix.AlgorithmIdentifier{Algorithm: oidMGF1, Parameters: asn1.RawValue{FullBytes: mgfParams}},
+			SaltLength: hash.Size(),
+		})
+		if err != nil {
+			return pkix.AlgorithmIdentifier{}, err
+		}
+
+		return pkix.AlgorithmIdentifier{Algorithm: oid, Parameters: asn1.RawValue{FullBytes: params}}, nil
+
+	case sigAlgo == x509.SHA256WithRSA || sigAlgo == x509.SHA384WithRSA || sigAlgo == x509.SHA512WithRSA:
+		return pkix.AlgorithmIdentifier{Algorithm: oid, Parameters: asn1.NullRawValue}, nil
+	}
+
+	return pkix.AlgorithmIdentifier{Algorithm: oid}, nil
+}
+
+// signatureHash returns the hash of the CSR signature algorithm, and true for RSA PSS
+func signatureHash(sigAlgo x509.SignatureAlgorithm) (crypto.Hash, bool) {
+
+	switch sigAlgo {
+	case x509.SHA256WithRSA, x509.ECDSAWithSHA256:
+		return crypto.SHA256, false
+	case x509.SHA384WithRSA, x509.ECDSAWithSHA384:
+		return crypto.SHA384, false
+	case x509.SHA512WithRSA, x509.ECDSAWithSHA512:
+		return crypto.SHA512, false
+	case x509.SHA256WithRSAPSS:
+		return crypto.SHA256, true
+	case x509.SHA384WithRSAPSS:
+		return crypto.SHA384, true
+	case x509.SHA512WithRSAPSS:
+		return crypto.SHA512, true
+	}
+
+	// x509.PureEd25519
+	return crypto.Hash(0), false
+}
@@ -0,0 +1,246 @@
+package transit
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cfssl/config"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/internal/transittest"
+)
+
+// TestExtKeyUsageOIDs checks extKeyUsageOIDs against the OIDs encoded by
+// crypto/x509 for the cfssl extended key usages
+func TestExtKeyUsageOIDs(t *testing.T) {
+
+	if len(extKeyUsageOIDs) != len(config.ExtKeyUsage) {
+		t.Errorf("%d extended key usage OIDs, want %d cfssl extended key usages", len(extKeyUsageOIDs), len(config.ExtKeyUsage))
+	}
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, eku := range config.ExtKeyUsage {
+
+		tpl := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: name},
+			NotBefore:    time.Now(),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{eku},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &priv.PublicKey, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var oids []asn1.ObjectIdentifier
+		for _, ext := range cert.Extensions {
+			if ext.Id.Equal(oidExtensionExtendedKeyUsage) {
+				_, err = asn1.Unmarshal(ext.Value, &oids)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+
+		want, ok := extKeyUsageOIDs[name]
+		if !ok {
+			t.Errorf("missing OID of cfssl extended key usage '%s'", name)
+			continue
+		}
+		if len(oids) != 1 || !oids[0].Equal(want) {
+			t.Errorf("OID of '%s' = %v, want x509 %v", name, want, oids)
+		}
+	}
+}
+
+func TestChallengePassword(t *testing.T) {
+
+	signers := newTestSigners(t)
+
+	tests := []struct {
+		name     string
+		keyType  string
+		opts     CSROptions
+		password string
+		// ASN.1 string tag
+		tag int
+	}{
+		{name: "rsa pkcs1v15", keyType: "rsa-2048", password: "secret-123", tag: asn1.TagPrintableString},
+		{name: "rsa pss", keyType: "rsa-2048", opts: CSROptions{SigAlg: "pss", HashAlg: "sha2-384"}, password: "secret", tag: asn1.TagPrintableString},
+		{name: "ecdsa", keyType: "ecdsa-p256", password: "pass_word!", tag: asn1.TagUTF8String},
+		{name: "ed25519", keyType: "ed25519", password: "mot de passe é", tag: asn1.TagUTF8String},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			key := transittest.NewKey(tt.keyType, signers[tt.keyType])
+			tc := newTestTransitClient(t, "csr", key)
+
+			spec := &CSRSpec{
+				Subject:           CSRSubject{CommonName: "example.com"},
+				DNSNames:          []string{"example.com"},
+				IPAddresses:       []string{"192.0.2.1"},
+				ExtKeyUsage:       []string{"server auth"},
+				ChallengePassword: tt.password,
+			}
+			out := filepath.Join(t.TempDir(), "example.csr")
+			opts := tt.opts
+			opts.Format = "der"
+
+			err := tc.GenCSRFromSpec(spec, 0, opts, out)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			der, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req, err := x509.ParseCertificateRequest(der)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = req.CheckSignature()
+			if err != nil {
+				t.Fatalf("CSR signature: %v", err)
+			}
+			if key.SignCount != 1 {
+				t.Errorf("CSR signed %d times by transit, want once", key.SignCount)
+			}
+			if !reflect.DeepEqual(req.PublicKey, signers[tt.keyType].Public()) {
+				t.Errorf("CSR public key is not the transit public key")
+			}
+			if !reflect.DeepEqual(req.DNSNames, spec.DNSNames) {
+				t.Errorf("DNS names = %v, want %v", req.DNSNames, spec.DNSNames)
+			}
+			if len(req.IPAddresses) != 1 || req.IPAddresses[0].String() != "192.0.2.1" {
+				t.Errorf("IP addresses = %v, want %v", req.IPAddresses, spec.IPAddresses)
+			}
+			if len(req.Extensions) != 2 {
+				t.Errorf("CSR has %d extensions, want subject alternative names and extended key usage", len(req.Extensions))
+			}
+
+			var csr certificateRequest
+			_, err = asn1.Unmarshal(der, &csr)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			found := false
+			for _, raw := range csr.TBSCSR.RawAttributes {
+				var attr struct {
+					Type   asn1.ObjectIdentifier
+					Values []asn1.RawValue `asn1:"set"`
+				}
+				_, err = asn1.Unmarshal(raw.FullBytes, &attr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !attr.Type.Equal(oidChallengePassword) {
+					continue
+				}
+
+				found = true
+				if len(attr.Values) != 1 || attr.Values[0].Tag != tt.tag || string(attr.Values[0].Bytes) != tt.password {
+					t.Errorf("challenge password = %+v, want %q with tag %d", attr.Values, tt.password, tt.tag)
+				}
+			}
+			if !found {
+				t.Error("missing challenge password attribute")
+			}
+		})
+	}
+}
+
+func TestChallengePasswordAttributeInvalid(t *testing.T) {
+
+	long := make([]byte, maxChallengePasswordLength+1)
+	for i := range long {
+		long[i] = 'a'
+	}
+
+	for _, password := range []string{string(long), "\xff\xfe"} {
+		_, err := challengePasswordAttribute(password)
+		if err == nil {
+			t.Errorf("challengePasswordAttribute(%q) succeeded, want error", password)
+		}
+	}
+}
+
+func TestSignatureAlgorithmIdentifier(t *testing.T) {
+
+	signers := newTestSigners(t)
+
+	tests := []struct {
+		keyType string
+		sigAlgo x509.SignatureAlgorithm
+	}{
+		{keyType: "rsa-2048", sigAlgo: x509.SHA256WithRSA},
+		{keyType: "rsa-2048", sigAlgo: x509.SHA384WithRSA},
+		{keyType: "rsa-2048", sigAlgo: x509.SHA512WithRSA},
+		{keyType: "rsa-2048", sigAlgo: x509.SHA256WithRSAPSS},
+		{keyType: "rsa-2048", sigAlgo: x509.SHA384WithRSAPSS},
+		{keyType: "rsa-2048", sigAlgo: x509.SHA512WithRSAPSS},
+		{keyType: "ecdsa-p256", sigAlgo: x509.ECDSAWithSHA256},
+		{keyType: "ecdsa-p256", sigAlgo: x509.ECDSAWithSHA384},
+		{keyType: "ecdsa-p256", sigAlgo: x509.ECDSAWithSHA512},
+		{keyType: "ed25519", sigAlgo: x509.PureEd25519},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sigAlgo.String(), func(t *testing.T) {
+
+			// as encoded by x509
+			der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{SignatureAlgorithm: tt.sigAlgo}, signers[tt.keyType])
+			if err != nil {
+				t.Fatal(err)
+			}
+			var csr certificateRequest
+			_, err = asn1.Unmarshal(der, &csr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := asn1.Marshal(csr.SignatureAlgorithm)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			algo, err := signatureAlgorithmIdentifier(tt.sigAlgo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := asn1.Marshal(algo)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(got, want) {
+				t.Errorf("AlgorithmIdentifier = %x, want %x", got, want)
+			}
+		})
+	}
+
+	_, err := signatureAlgorithmIdentifier(x509.SHA1WithRSA)
+	if err == nil {
+		t.Error("signatureAlgorithmIdentifier(SHA1WithRSA) succeeded, want error")
+	}
+}
@@ -0,0 +1,104 @@
+package transit
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/internal/transittest"
+)
+
+func TestX509SignatureAlgorithm(t *testing.T) {
+
+	tests := []struct {
+		keyType string
+		opts    CSROptions
+		want    x509.SignatureAlgorithm
+	}{
+		// cfssl defaults
+		{keyType: "rsa-2048", want: x509.SHA256WithRSA},
+		{keyType: "rsa-3072", want: x509.SHA384WithRSA},
+		{keyType: "rsa-4096", want: x509.SHA512WithRSA},
+		{keyType: "ecdsa-p256", want: x509.ECDSAWithSHA256},
+		{keyType: "ecdsa-p384", want: x509.ECDSAWithSHA384},
+		{keyType: "ecdsa-p521", want: x509.ECDSAWithSHA512},
+		{keyType: "ed25519", want: x509.PureEd25519},
+
+		{keyType: "rsa-2048", opts: CSROptions{SigAlg: "pss"}, want: x509.SHA256WithRSAPSS},
+		{keyType: "rsa-3072", opts: CSROptions{SigAlg: "pss"}, want: x509.SHA384WithRSAPSS},
+		{keyType: "rsa-4096", opts: CSROptions{HashAlg: "sha2-256"}, want: x509.SHA256WithRSA},
+		{keyType: "rsa-2048", opts: CSROptions{SigAlg: "pss", HashAlg: "sha2-512"}, want: x509.SHA512WithRSAPSS},
+		{keyType: "ecdsa-p256", opts: CSROptions{HashAlg: "sha2-384"}, want: x509.ECDSAWithSHA384},
+	}
+
+	for _, tt := range tests {
+		got, err := x509SignatureAlgorithm(tt.keyType, tt.opts)
+		if err != nil {
+			t.Errorf("%s %+v: %v", tt.keyType, tt.opts, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s %+v: signature algorithm = %s, want %s", tt.keyType, tt.opts, got, tt.want)
+		}
+	}
+
+	invalid := []struct {
+		keyType string
+		opts    CSROptions
+	}{
+		{keyType: "ed25519", opts: CSROptions{HashAlg: "sha2-256"}},
+		{keyType: "ecdsa-p256", opts: CSROptions{SigAlg: "pss"}},
+		{keyType: "rsa-2048", opts: CSROptions{SigAlg: "pkcs1"}},
+		{keyType: "rsa-2048", opts: CSROptions{HashAlg: "sha2-224"}},
+		{keyType: "aes256-gcm96"},
+	}
+
+	for _, tt := range invalid {
+		_, err := x509SignatureAlgorithm(tt.keyType, tt.opts)
+		if err == nil {
+			t.Errorf("%s %+v: succeeded, want error", tt.keyType, tt.opts)
+		}
+	}
+}
+
+func TestGenCSRWritesOutputBeforeKV2(t *testing.T) {
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the fake transit backend has no kv2 mount
+	tc := newTestTransitClient(t, "csr", transittest.NewKey("ecdsa-p256", priv))
+
+	spec := &CSRSpec{Subject: CSRSubject{CommonName: "example.com"}, DNSNames: []string{"example.com"}}
+	out := filepath.Join(t.TempDir(), "example.csr")
+	opts := CSROptions{Format: "der", KV2Mount: "secret", KV2Path: "csr/example"}
+
+	err = tc.GenCSRFromSpec(spec, 0, opts, out)
+	if err == nil {
+		t.Fatal("GenCSRFromSpec succeeded without kv2, want error")
+	}
+
+	der, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("CSR not written before kv2: %v", err)
+	}
+	req, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Subject.CommonName != "example.com" || !req.PublicKey.(*ecdsa.PublicKey).Equal(&priv.PublicKey) {
+		t.Errorf("CSR subject %s, public key %v", req.Subject, req.PublicKey)
+	}
+
+	// existing output
+	err = tc.GenCSRFromSpec(spec, 0, CSROptions{}, out)
+	if err == nil {
+		t.Error("GenCSRFromSpec overwrote the existing output, want error")
+	}
+}
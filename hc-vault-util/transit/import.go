@@ -1,124 +1,201 @@
 package transit
 
 import (
-	"crypto/ecdsa"
+	"crypto"
 	"crypto/ed25519"
-	"crypto/rand"
-	"crypto/rsa"
-	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
-	"encoding/pem"
 	"fmt"
 	"os"
-
-	"github.com/google/tink/go/kwp/subtle"
+	"strings"
 )
 
-func (t *TransitClient) ImportPrivateKey(keyFile string) error {
-	// read private key file
-	data, err := os.ReadFile(keyFile)
+// ImportPrivateKey imports the PEM private key from keyFile into transit.
+//
+// PKCS8, encrypted PKCS8, PKCS1 and SEC1 keys are supported, the
+// passphrase is only read if the key is encrypted.
+func (t *TransitClient) ImportPrivateKey(keyFile string, passphrase *PassphraseSource) error {
+
+	privKey, err := t.loadPrivateKey(keyFile, passphrase)
 	if err != nil {
-		t.logger.Error("Error reading private key", "error", err)
 		return err
 	}
 
-	key, _ := pem.Decode(data)
-	if key == nil {
-		return fmt.Errorf("Error Decoding PEM file, invalid format")
-	}
-	privKey, err := x509.ParsePKCS8PrivateKey(key.Bytes)
+	return t.importPrivateKey(privKey)
+}
+
+// ImportCiphertext imports the base64 ciphertext from ciphertextFile, previously
+// wrapped offline for the wrapping key of the transit mount, as keyType
+func (t *TransitClient) ImportCiphertext(ciphertextFile, keyType string) error {
+
+	// transit cannot tell the key type of the wrapped key
+	err := validateImportKeyType(keyType)
 	if err != nil {
-		t.logger.Error("Error parsing PEM PKCS8 private key", "error", err)
-		t.logger.Warn("You can use openssl to convert your key into PKCS8 PEM format:\n\n  openssl pkcs8 -topk8 -outform PEM -in key.pem -out key_pk8.pem -nocrypt \n")
+		t.logger.Error("Invalid key type of ciphertext", "error", err)
 		return err
 	}
 
-	// case key to derive hc vault key type
-	var keyType string
-	switch priv := privKey.(type) {
-	case *rsa.PrivateKey:
+	// read ciphertext file
+	data, err := os.ReadFile(ciphertextFile)
+	if err != nil {
+		t.logger.Error("Error reading ciphertext", "error", err)
+		return err
+	}
 
-		// *8 to convert bytes to bits
-		bitsSize := priv.Size() * 8
-		keyType = fmt.Sprintf("rsa-%d", bitsSize)
+	base64Ciphertext := strings.TrimSpace(string(data))
+	_, err = base64.StdEncoding.DecodeString(base64Ciphertext)
+	if err != nil {
+		t.logger.Error("Error decoding ciphertext, must be base64 encoded", "error", err)
+		return err
+	}
 
-	case *ecdsa.PrivateKey:
+	err = t.prepareImport(keyType)
+	if err != nil {
+		return err
+	}
 
-		keyType = fmt.Sprintf("ecdsa-p%d", priv.Params().BitSize)
-	case *ed25519.PrivateKey:
+	return t.importWrappedKey(base64Ciphertext, keyType)
+}
 
-		keyType = "ed25519"
-	default:
-		supportedType := []string{"rsa-2048", "rsa-3072", "rsa-4096", "ecdsa-p256", "ecdsa-p384", "ecdsa-p521", "ed25519"}
-		t.logger.Error("Unsupported key type", "format", supportedType)
-		return fmt.Errorf("Unsupported key type")
+// loadPrivateKey reads and parses the PEM private key from keyFile
+func (t *TransitClient) loadPrivateKey(keyFile string, passphrase *PassphraseSource) (crypto.PrivateKey, error) {
+	// read private key file
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		t.logger.Error("Error reading private key", "error", err)
+		return nil, err
+	}
 
+	privKey, err := parsePrivateKeyPEM(data, passphrase)
+	if err != nil {
+		t.logger.Error("Error parsing PEM private key", "error", err)
+		return nil, err
 	}
 
-	t.logger.Info("Importing key type", "type", keyType)
+	return privKey, nil
+}
+
+// importPrivateKey wraps privKey and imports it into transit
+func (t *TransitClient) importPrivateKey(privKey crypto.PrivateKey) error {
 
-	// implementing steps from doc
-	// https://developer.hashicorp.com/vault/docs/secrets/transit/key-wrapping-guide#software-example-go
-	t.logger.Debug("generating wrapping key from transit...")
-	wrappingKey, err := t.getWrappingKey()
+	pkcs8Key, keyType, err := t.privateKeyMaterial(privKey)
 	if err != nil {
-		t.logger.Error("error generating wrapping key", "error", err)
 		return err
 	}
 
-	t.logger.Debug("generating AES ephemeral key...")
-	ephemeralAESKey, err := t.genAESKey()
+	err = t.importKeyMaterial(pkcs8Key, keyType)
 	if err != nil {
-		t.logger.Error("error generating AES ephemeral key", "error", err)
 		return err
 	}
 
-	t.logger.Debug("generating key wrap from AES key")
-	wrapKWP, err := subtle.NewKWP(ephemeralAESKey)
+	// the key is already imported and usable: verification cannot undo the import
+	if t.importOpts.Verify {
+		err = t.verifyImportedKey(privKey)
+		if err != nil {
+			path := fmt.Sprintf("%s/keys/%s", t.transitMount, t.keyName)
+			t.logger.Error("Imported key failed verification, the key REMAINS IMPORTED in transit and must be deleted or rotated", "path", path, "error", err)
+			return fmt.Errorf("imported key %s failed verification and remains in transit: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// privateKeyMaterial returns privKey in PKCS8 DER format and its transit key type
+func (t *TransitClient) privateKeyMaterial(privKey crypto.PrivateKey) ([]byte, string, error) {
+
+	// derive hc vault key type
+	keyType, err := transitKeyType(privKey)
+	if err != nil {
+		t.logger.Error("Unsupported key type", "error", err)
+		return nil, "", err
+	}
+
+	// x509 only marshals ed25519 keys by value
+	if priv, ok := privKey.(*ed25519.PrivateKey); ok {
+		privKey = *priv
+	}
+
+	// transit expects the target key in PKCS8 DER format
+	pkcs8Key, err := x509.MarshalPKCS8PrivateKey(privKey)
+	if err != nil {
+		t.logger.Error("Error converting private key to PKCS8", "error", err)
+		return nil, "", err
+	}
+
+	return pkcs8Key, keyType, nil
+}
+
+// importKeyMaterial wraps the target key and imports it into transit as keyType
+func (t *TransitClient) importKeyMaterial(targetKey []byte, keyType string) error {
+
+	err := t.prepareImport(keyType)
 	if err != nil {
-		t.logger.Error("error generating key Wrap", "error", err)
 		return err
 	}
 
-	t.logger.Debug("Wrapping private key...")
-	wrappedTargetKey, err := wrapKWP.Wrap(key.Bytes)
+	base64Ciphertext, err := t.wrapKeyMaterial(targetKey)
 	if err != nil {
-		t.logger.Error("error wrapping private key", "error", err)
 		return err
 	}
 
-	//
-	t.logger.Debug("encrypting AES ephemeral key with RSA wrapping key")
-	wrappedAESKey, err := rsa.EncryptOAEP(
-		sha256.New(),
-		rand.Reader,
-		wrappingKey,
-		ephemeralAESKey,
-		[]byte{},
-	)
+	return t.importWrappedKey(base64Ciphertext, keyType)
+}
+
+// prepareImport validates the import of keyType before sending anything to transit
+func (t *TransitClient) prepareImport(keyType string) error {
+
+	t.logger.Info("Importing key type", "type", keyType)
+
+	err := t.importOpts.Validate(keyType)
 	if err != nil {
-		t.logger.Error("encrypting AES ephemeral key", "error", err)
+		t.logger.Error("Invalid import options", "error", err)
 		return err
 	}
 
-	// combined the payload and base64 encode
-	combinedCiphertext := append(wrappedAESKey, wrappedTargetKey...)
-	base64Ciphertext := base64.StdEncoding.EncodeToString(combinedCiphertext)
+	if t.importOpts.NewVersion {
+		// import_version requires the same key type as the existing key
+		err = t.checkExistingKeyType(keyType)
+		if err != nil {
+			t.logger.Error("Error validating existing transit key", "error", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// importWrappedKey imports the wrapped key into transit
+func (t *TransitClient) importWrappedKey(base64Ciphertext, keyType string) error {
 
 	// import into transit backend
-	return t.TransitImportKey(t.transitMount, t.keyName, "SHA256", base64Ciphertext, keyType)
+	if t.importOpts.NewVersion {
+		return t.TransitImportKeyVersion(t.transitMount, t.keyName, t.importOpts.hashFunction(), base64Ciphertext)
+	}
+	return t.TransitImportKey(t.transitMount, t.keyName, base64Ciphertext, keyType, t.importOpts)
 
 }
 
-func (t *TransitClient) TransitImportKey(transitMount, keyName, hashFunc, base64Ciphertext, keyType string) error {
+// TransitImportKey imports the wrapped key as a new key of type keyType with the key policy from opts
+func (t *TransitClient) TransitImportKey(transitMount, keyName, base64Ciphertext, keyType string, opts ImportOptions) error {
 
 	args := map[string]interface{}{
 		// transit required input to base64 encoded
-		"ciphertext":     base64Ciphertext,
-		"hash_function ": hashFunc,
-		"type":           keyType,
-		"exportable":     false,
+		"ciphertext":             base64Ciphertext,
+		"hash_function":          opts.hashFunction(),
+		"type":                   keyType,
+		"exportable":             opts.Exportable,
+		"allow_plaintext_backup": opts.AllowPlaintextBackup,
+		"allow_rotation":         opts.AllowRotation,
+		"derived":                opts.Derived,
+	}
+
+	if opts.AutoRotatePeriod != "" {
+		args["auto_rotate_period"] = opts.AutoRotatePeriod
+	}
+
+	if opts.Context != "" {
+		args["context"] = opts.Context
 	}
 
 	apiPath := fmt.Sprintf("%s/keys/%s/import", transitMount, keyName)
@@ -131,55 +208,38 @@ func (t *TransitClient) TransitImportKey(transitMount, keyName, hashFunc, base64
 
 }
 
-func (t *TransitClient) getWrappingKey() (*rsa.PublicKey, error) {
-
-	// transit key api path
-	apiPath := fmt.Sprintf("%s/wrapping_key", t.transitMount)
-	// read transit key
-	keyInfo, err := t.client.Logical().ReadWithContext(t.ctx, apiPath)
-	if err != nil {
-		return nil, err
-	}
+// TransitImportKeyVersion imports the wrapped key as a new version of the existing key
+func (t *TransitClient) TransitImportKeyVersion(transitMount, keyName, hashFunc, base64Ciphertext string) error {
 
-	// parse key type
-	publicKeyPem, ok := keyInfo.Data["public_key"].(string)
-	if !ok {
-		return nil, fmt.Errorf("error parsing wrapping key %s", apiPath)
+	args := map[string]interface{}{
+		// transit required input to base64 encoded
+		"ciphertext":    base64Ciphertext,
+		"hash_function": hashFunc,
 	}
 
-	t.logger.Debug("go wrapping key", "public_key", publicKeyPem)
-
-	block, _ := pem.Decode([]byte(publicKeyPem))
-
-	if block == nil {
-		return nil, fmt.Errorf("error Pem Decoding pub key")
-	}
-	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	apiPath := fmt.Sprintf("%s/keys/%s/import_version", transitMount, keyName)
+	_, err := t.client.Logical().WriteWithContext(t.ctx, apiPath, args)
 	if err != nil {
-		return nil, err
-	}
-
-	// cast is as a RSA
-	var wrappingKey *rsa.PublicKey
-	switch pub := pub.(type) {
-	case *rsa.PublicKey:
-
-		wrappingKey = pub
-
-	default:
-		return nil, fmt.Errorf("unknown type of wrapping key for %s", apiPath)
+		return err
 	}
 
-	return wrappingKey, nil
+	return nil
 
 }
 
-func (t *TransitClient) genAESKey() ([]byte, error) {
-	ephemeralAESKey := make([]byte, 32)
-	_, err := rand.Read(ephemeralAESKey)
+// checkExistingKeyType returns an error if the existing transit key
+// is not of type keyType
+func (t *TransitClient) checkExistingKeyType(keyType string) error {
+
+	k, err := t.syncTransitKey()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return ephemeralAESKey, nil
+	if k.Type != keyType {
+		return fmt.Errorf("key type mismatch: transit key %s/keys/%s is '%s' but private key is '%s'", k.MountPath, k.Name, k.Type, keyType)
+	}
+
+	t.logger.Debug("existing transit key", "type", k.Type, "latest_version", k.Version)
+	return nil
 }
@@ -0,0 +1,127 @@
+package transit
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
+)
+
+const defaultImportHashFunction = "SHA256"
+
+var (
+	// hash functions supported by transit for the RSA-OAEP
+	// encryption of the ephemeral AES key
+	importHashFunctions = map[string]func() hash.Hash{
+		"SHA1":   sha1.New,
+		"SHA224": sha256.New224,
+		"SHA256": sha256.New,
+		"SHA384": sha512.New384,
+		"SHA512": sha512.New,
+	}
+
+	// key types supporting key derivation in transit
+	derivableKeyTypes = map[string]bool{
+		"aes128-gcm96":      true,
+		"aes256-gcm96":      true,
+		"chacha20-poly1305": true,
+		"ed25519":           true,
+	}
+)
+
+// ImportOptions controls how a key is imported into transit
+type ImportOptions struct {
+	// import as a new version of an existing key
+	// instead of creating a new key
+	NewVersion bool `yaml:"as_new_version"`
+
+	// verify the imported private key with transit after import, only for
+	// private key and pkcs12 sources. The key is not removed from transit
+	// if verification fails.
+	Verify bool `yaml:"verify"`
+
+	// hash function used for RSA-OAEP one of SHA1, SHA224, SHA256 (default), SHA384, SHA512
+	HashFunction string `yaml:"hash_function"`
+
+	// key policy (only for new keys)
+	Exportable           bool `yaml:"exportable"`
+	AllowPlaintextBackup bool `yaml:"allow_plaintext_backup"`
+	AllowRotation        bool `yaml:"allow_rotation"`
+	// auto rotation period as transit (e.g. '24h', '30d' or seconds), or empty to disable
+	AutoRotatePeriod string `yaml:"auto_rotate_period"`
+	// key derivation, with optional base64 encoded context
+	Derived bool   `yaml:"derived"`
+	Context string `yaml:"context"`
+}
+
+// Validate returns an error if the options are invalid for keyType
+func (o ImportOptions) Validate(keyType string) error {
+
+	if o.HashFunction != "" {
+		if _, ok := importHashFunctions[o.HashFunction]; !ok {
+			return fmt.Errorf("unsupported hash function '%s', must be one of SHA1, SHA224, SHA256, SHA384, SHA512", o.HashFunction)
+		}
+	}
+
+	if o.NewVersion {
+		// key policy is set when the key is created
+		// and cannot be changed by import_version
+		if o.Exportable || o.AllowPlaintextBackup || o.AllowRotation || o.AutoRotatePeriod != "" || o.Derived || o.Context != "" {
+			return fmt.Errorf("key policy options cannot be set when importing a new version of an existing key")
+		}
+		return nil
+	}
+
+	if o.AutoRotatePeriod != "" {
+		// same format as transit, passed as is
+		period, err := parseutil.ParseDurationSecond(o.AutoRotatePeriod)
+		if err != nil {
+			return fmt.Errorf("invalid auto rotate period '%s': %w", o.AutoRotatePeriod, err)
+		}
+
+		// transit rejects periods shorter than 1h, 0 disables auto rotation
+		if period != 0 && period < time.Hour {
+			return fmt.Errorf("invalid auto rotate period '%s', must be 0 or at least 1h", o.AutoRotatePeriod)
+		}
+
+		if period != 0 && !o.AllowRotation {
+			return fmt.Errorf("auto rotate period requires allow rotation")
+		}
+	}
+
+	if o.Derived && !derivableKeyTypes[keyType] {
+		return fmt.Errorf("key derivation is not supported for key type '%s'", keyType)
+	}
+
+	if o.Context != "" {
+		if !o.Derived {
+			return fmt.Errorf("context requires a derived key")
+		}
+
+		_, err := base64.StdEncoding.DecodeString(o.Context)
+		if err != nil {
+			return fmt.Errorf("context must be base64 encoded: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// hashFunction returns the transit hash_function name
+func (o ImportOptions) hashFunction() string {
+	if o.HashFunction == "" {
+		return defaultImportHashFunction
+	}
+	return o.HashFunction
+}
+
+// oaepHash returns a new hash for the RSA-OAEP encryption
+// matching the transit hash_function
+func (o ImportOptions) oaepHash() hash.Hash {
+	return importHashFunctions[o.hashFunction()]()
+}
@@ -0,0 +1,39 @@
+package transit
+
+import "testing"
+
+func TestImportOptionsAutoRotatePeriod(t *testing.T) {
+
+	tests := []struct {
+		period  string
+		wantErr bool
+	}{
+		{period: "720h"},
+		{period: "30d"},
+		{period: "86400"},
+		{period: "1h30m"},
+		{period: "0"},
+		{period: "0s"},
+		{period: "30m", wantErr: true},
+		{period: "3599", wantErr: true},
+		{period: "30x", wantErr: true},
+		{period: "1.5d", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		opts := ImportOptions{AllowRotation: true, AutoRotatePeriod: tt.period}
+		err := opts.Validate("rsa-2048")
+		if tt.wantErr && err == nil {
+			t.Errorf("auto rotate period '%s': succeeded, want error", tt.period)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("auto rotate period '%s': %v", tt.period, err)
+		}
+	}
+
+	// requires allow rotation
+	err := ImportOptions{AutoRotatePeriod: "30d"}.Validate("rsa-2048")
+	if err == nil {
+		t.Error("auto rotate period without allow rotation succeeded, want error")
+	}
+}
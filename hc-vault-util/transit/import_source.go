@@ -0,0 +1,105 @@
+package transit
+
+import (
+	"fmt"
+)
+
+// ImportSource describes the key material to import, exactly one of
+// KeyFile, PKCS12, SymmetricKey or CiphertextFile must be set
+type ImportSource struct {
+	// PEM private key (PKCS8, encrypted PKCS8, PKCS1 or SEC1)
+	KeyFile string `yaml:"key_file"`
+	// PKCS12 bundle
+	PKCS12 string `yaml:"pkcs12"`
+	// symmetric or hmac key material
+	SymmetricKey string `yaml:"symmetric_key"`
+	// base64 ciphertext wrapped offline
+	CiphertextFile string `yaml:"ciphertext_file"`
+
+	// transit key type for symmetric key or ciphertext
+	KeyType string `yaml:"key_type"`
+	// encoding of symmetric key one of raw (default), hex, base64
+	KeyEncoding string `yaml:"key_encoding"`
+
+	// passphrase of encrypted private key or PKCS12 bundle
+	PassphraseEnv  string `yaml:"passphrase_env"`
+	PassphraseFile string `yaml:"passphrase_file"`
+
+	// kv2 secret where to store PKCS12 certificate and chain
+	CertKV2Mount string `yaml:"cert_kv2_mount"`
+	CertKV2Path  string `yaml:"cert_kv2_path"`
+}
+
+// Validate returns an error if the source is incomplete or ambiguous
+func (s ImportSource) Validate() error {
+
+	count := 0
+	for _, f := range []string{s.KeyFile, s.PKCS12, s.SymmetricKey, s.CiphertextFile} {
+		if f != "" {
+			count++
+		}
+	}
+
+	if count != 1 {
+		return fmt.Errorf("exactly one of private key, pkcs12, symmetric key or ciphertext file is required")
+	}
+
+	if (s.SymmetricKey != "" || s.CiphertextFile != "") && s.KeyType == "" {
+		return fmt.Errorf("key type is required for symmetric key and ciphertext file")
+	}
+
+	if s.PassphraseEnv != "" && s.PassphraseFile != "" {
+		return fmt.Errorf("passphrase env and passphrase file are mutually exclusive")
+	}
+
+	return nil
+}
+
+// ValidateOptions returns an error if the import options cannot be used with the source
+func (s ImportSource) ValidateOptions(opts ImportOptions) error {
+
+	// verify requires the local private key
+	if opts.Verify && (s.SymmetricKey != "" || s.CiphertextFile != "") {
+		return fmt.Errorf("verify is only supported for private key and pkcs12 imports")
+	}
+
+	return nil
+}
+
+// Import imports the key material from src with the current key properties and import options
+func (t *TransitClient) Import(src ImportSource) error {
+
+	err := src.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = src.ValidateOptions(t.importOpts)
+	if err != nil {
+		return err
+	}
+
+	passphrase := NewPassphraseSource(src.PassphraseEnv, src.PassphraseFile)
+
+	switch {
+	case src.PKCS12 != "":
+		certKV2Mount := src.CertKV2Mount
+		if certKV2Mount == "" {
+			certKV2Mount = "secret"
+		}
+		return t.ImportPKCS12(src.PKCS12, passphrase, certKV2Mount, src.CertKV2Path)
+
+	case src.SymmetricKey != "":
+		keyEncoding := src.KeyEncoding
+		if keyEncoding == "" {
+			keyEncoding = "raw"
+		}
+		return t.ImportSymmetricKey(src.SymmetricKey, src.KeyType, keyEncoding)
+
+	case src.CiphertextFile != "":
+		return t.ImportCiphertext(src.CiphertextFile, src.KeyType)
+
+	default:
+		return t.ImportPrivateKey(src.KeyFile, passphrase)
+	}
+}
@@ -0,0 +1,474 @@
+// Package transittest provides a fake Vault transit backend for tests
+package transittest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/tink/go/kwp/subtle"
+	vault "github.com/hashicorp/vault/api"
+)
+
+// Mount is the path of the fake transit backend
+const Mount = "transit"
+
+var (
+	// transit hash_algorithm of the sign API
+	vaultHashes = map[string]crypto.Hash{
+		"sha1":     crypto.SHA1,
+		"sha2-224": crypto.SHA224,
+		"sha2-256": crypto.SHA256,
+		"sha2-384": crypto.SHA384,
+		"sha2-512": crypto.SHA512,
+	}
+
+	// transit hash_function of the OAEP wrapping key in imports
+	importHashes = map[string]crypto.Hash{
+		"SHA1":   crypto.SHA1,
+		"SHA224": crypto.SHA224,
+		"SHA256": crypto.SHA256,
+		"SHA384": crypto.SHA384,
+		"SHA512": crypto.SHA512,
+	}
+)
+
+// Key is a key of the fake transit backend
+type Key struct {
+	// transit key type, e.g. rsa-2048, ecdsa-p256, ed25519 or aes256-gcm96
+	Type string
+	// private key of version i+1, nil for symmetric key types
+	Versions []crypto.Signer
+	// min_decryption_version, 1 if not set
+	MinVersion int
+	// omit creation_time like old Vault versions
+	NoCreationTime bool
+	// signs with Signer instead of the private key of the version if set
+	Signer crypto.Signer
+
+	// key_version of the last sign request
+	LastSignVersion int
+	// number of sign requests
+	SignCount int
+	// unwrapped key material of the import and import_version requests
+	Imported [][]byte
+}
+
+// NewKey returns a key of keyType with the private keys of its versions
+func NewKey(keyType string, versions ...crypto.Signer) *Key {
+	return &Key{Type: keyType, Versions: versions}
+}
+
+// NewSymmetricKey returns a key of keyType without public keys, e.g. aes256-gcm96 or hmac
+func NewSymmetricKey(keyType string, versions int) *Key {
+	return &Key{Type: keyType, Versions: make([]crypto.Signer, versions)}
+}
+
+// GenerateKey returns a key of keyType, one of rsa-2048, rsa-3072, rsa-4096,
+// ecdsa-p256, ecdsa-p384, ecdsa-p521 or ed25519, with new private keys
+func GenerateKey(t testing.TB, keyType string, versions int) *Key {
+	t.Helper()
+
+	k := NewKey(keyType)
+	for i := 0; i < versions; i++ {
+		k.Versions = append(k.Versions, GenerateSigner(t, keyType))
+	}
+
+	return k
+}
+
+// GenerateSigner returns a new private key of the asymmetric transit keyType
+func GenerateSigner(t testing.TB, keyType string) crypto.Signer {
+	t.Helper()
+
+	var (
+		priv crypto.Signer
+		err  error
+	)
+	switch keyType {
+	case "rsa-2048":
+		priv, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "rsa-3072":
+		priv, err = rsa.GenerateKey(rand.Reader, 3072)
+	case "rsa-4096":
+		priv, err = rsa.GenerateKey(rand.Reader, 4096)
+	case "ecdsa-p256":
+		priv, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "ecdsa-p384":
+		priv, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case "ecdsa-p521":
+		priv, err = ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	case "ed25519":
+		_, priv, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		t.Fatalf("unsupported key type %s", keyType)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return priv
+}
+
+// Server is a fake transit backend mounted at 'transit', serving
+// 'transit/wrapping_key', 'transit/keys/<name>',
+// 'transit/keys/<name>/import[_version]' and 'transit/sign/<name>[/<hash>]'
+type Server struct {
+	mu   sync.Mutex
+	keys map[string]*Key
+	// generated on the first wrapping_key read
+	wrappingKey *rsa.PrivateKey
+
+	client *vault.Client
+}
+
+// NewServer starts a fake transit backend with keys by name, closed at the end of the test
+func NewServer(t testing.TB, keys map[string]*Key) *Server {
+	t.Helper()
+
+	s := &Server{keys: map[string]*Key{}}
+	for name, k := range keys {
+		s.keys[name] = k
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(srv.Close)
+
+	client, err := vault.NewClient(&vault.Config{Address: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken("test")
+	s.client = client
+
+	return s
+}
+
+// NewClient returns a vault client of a new fake transit backend with keys by name
+func NewClient(t testing.TB, keys map[string]*Key) *vault.Client {
+	t.Helper()
+
+	return NewServer(t, keys).Client()
+}
+
+// Client returns a vault client of the fake transit backend
+func (s *Server) Client() *vault.Client {
+	return s.client
+}
+
+// Key returns the key name, or nil if it does not exist
+func (s *Server) Key(name string) *Key {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.keys[name]
+}
+
+// SetKey adds or replaces the key name
+func (s *Server) SetKey(name string, k *Key) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[name] = k
+}
+
+// DeleteKey removes the key name, reads of the key then fail
+func (s *Server) DeleteKey(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keys, name)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/"+Mount+"/"), "/")
+	if len(parts) == 1 && parts[0] == "wrapping_key" && r.Method == http.MethodGet {
+		s.readWrappingKey(w)
+		return
+	}
+	if len(parts) < 2 {
+		http.NotFound(w, r)
+		return
+	}
+
+	name := parts[1]
+	k, ok := s.keys[name]
+
+	switch {
+	case parts[0] == "keys" && len(parts) == 3 && r.Method != http.MethodGet:
+		s.importKey(w, r, name, k, parts[2])
+
+	case !ok:
+		http.NotFound(w, r)
+
+	case parts[0] == "keys" && len(parts) == 2 && r.Method == http.MethodGet:
+		writeResponse(w, k.readResponse())
+
+	case parts[0] == "sign" && len(parts) <= 3:
+		hashAlg := "sha2-256"
+		if len(parts) == 3 {
+			hashAlg = parts[2]
+		}
+
+		sig, err := k.sign(r, hashAlg)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeResponse(w, map[string]interface{}{"signature": sig})
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// readWrappingKey writes the PEM public key of the RSA wrapping key
+func (s *Server) readWrappingKey(w http.ResponseWriter) {
+
+	if s.wrappingKey == nil {
+		priv, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.wrappingKey = priv
+	}
+
+	writeResponse(w, map[string]interface{}{"public_key": PublicKey(&s.wrappingKey.PublicKey)})
+}
+
+// unwrap returns the target key of the base64 ciphertext: the ephemeral AES
+// key encrypted with the wrapping key (RSA-OAEP), followed by the target key
+// wrapped with the AES key (KWP)
+func (s *Server) unwrap(ciphertext, hashFunction string) ([]byte, error) {
+
+	if s.wrappingKey == nil {
+		return nil, fmt.Errorf("wrapping key not read")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	size := s.wrappingKey.Size()
+	if len(data) <= size {
+		return nil, fmt.Errorf("invalid ciphertext length %d", len(data))
+	}
+
+	if hashFunction == "" {
+		hashFunction = "SHA256"
+	}
+	hash, ok := importHashes[hashFunction]
+	if !ok {
+		return nil, fmt.Errorf("unsupported hash function %s", hashFunction)
+	}
+
+	aesKey, err := rsa.DecryptOAEP(hash.New(), rand.Reader, s.wrappingKey, data[:size], []byte{})
+	if err != nil {
+		return nil, err
+	}
+
+	kwp, err := subtle.NewKWP(aesKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return kwp.Unwrap(data[size:])
+}
+
+// importKey records the key material of an import of a new key, or of a new version of k
+func (s *Server) importKey(w http.ResponseWriter, r *http.Request, name string, k *Key, op string) {
+
+	var req struct {
+		Ciphertext   string `json:"ciphertext"`
+		HashFunction string `json:"hash_function"`
+		Type         string `json:"type"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	keyMaterial, err := s.unwrap(req.Ciphertext, req.HashFunction)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case op == "import" && k == nil:
+		k = NewKey(req.Type, nil)
+		s.keys[name] = k
+	case op == "import":
+		http.Error(w, "key already exists", http.StatusBadRequest)
+		return
+	case op == "import_version" && k != nil:
+		k.Versions = append(k.Versions, nil)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	k.Imported = append(k.Imported, keyMaterial)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeResponse(w http.ResponseWriter, data map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	//nolint
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+}
+
+// symmetric returns true if the key type has no public key
+func (k *Key) symmetric() bool {
+	return k.Type != "ed25519" && !strings.HasPrefix(k.Type, "rsa-") && !strings.HasPrefix(k.Type, "ecdsa-")
+}
+
+// readResponse returns the transit read key response. As Vault, versions of
+// symmetric keys are their creation unix time, public keys of asymmetric keys
+// are PEM, or raw base64 for ed25519.
+func (k *Key) readResponse() map[string]interface{} {
+
+	versions := map[string]interface{}{}
+	for i, s := range k.Versions {
+		creationTime := time.Date(2024, 1, i+1, 0, 0, 0, 0, time.UTC)
+
+		if k.symmetric() || s == nil {
+			versions[strconv.Itoa(i+1)] = creationTime.Unix()
+			continue
+		}
+
+		v := map[string]interface{}{"public_key": PublicKey(s.Public()), "name": k.Type}
+		if !k.NoCreationTime {
+			v["creation_time"] = creationTime.Format(time.RFC3339Nano)
+		}
+		versions[strconv.Itoa(i+1)] = v
+	}
+
+	minVersion := k.MinVersion
+	if minVersion == 0 {
+		minVersion = 1
+	}
+
+	return map[string]interface{}{
+		"type":                   k.Type,
+		"latest_version":         len(k.Versions),
+		"min_decryption_version": minVersion,
+		"keys":                   versions,
+	}
+}
+
+// PublicKey returns pub encoded as the transit 'public_key': PEM, or raw base64 for ed25519
+func PublicKey(pub crypto.PublicKey) string {
+
+	if edPub, ok := pub.(ed25519.PublicKey); ok {
+		return base64.StdEncoding.EncodeToString(edPub)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		panic(err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// sign returns the 'vault:vN:' signature of the transit sign request
+func (k *Key) sign(r *http.Request, hashAlg string) (string, error) {
+
+	var req struct {
+		Input              string `json:"input"`
+		KeyVersion         int    `json:"key_version"`
+		Prehashed          bool   `json:"prehashed"`
+		SignatureAlgorithm string `json:"signature_algorithm"`
+		SaltLength         string `json:"salt_length"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return "", err
+	}
+
+	version := req.KeyVersion
+	if version == 0 {
+		version = len(k.Versions)
+	}
+	if version < k.MinVersion || version < 1 || version > len(k.Versions) {
+		return "", fmt.Errorf("invalid key version %d", version)
+	}
+
+	signer := k.Versions[version-1]
+	if k.Signer != nil {
+		signer = k.Signer
+	}
+	if signer == nil {
+		return "", fmt.Errorf("key type %s does not support signing", k.Type)
+	}
+	k.LastSignVersion = version
+	k.SignCount++
+
+	input, err := base64.StdEncoding.DecodeString(req.Input)
+	if err != nil {
+		return "", err
+	}
+
+	sig, err := signInput(signer, input, hashAlg, req.Prehashed, req.SignatureAlgorithm, req.SaltLength)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("vault:v%d:%s", version, base64.StdEncoding.EncodeToString(sig)), nil
+}
+
+// signInput signs the message, or digest if prehashed, as transit: ASN.1
+// signatures, PKCS#1 v1.5 or PSS signatures
+func signInput(signer crypto.Signer, input []byte, hashAlg string, prehashed bool, sigAlg, saltLength string) ([]byte, error) {
+
+	// ed25519 signs the message
+	if _, ok := signer.(ed25519.PrivateKey); ok {
+		return signer.Sign(rand.Reader, input, crypto.Hash(0))
+	}
+
+	hash, ok := vaultHashes[hashAlg]
+	if !ok {
+		return nil, fmt.Errorf("unsupported hash algorithm %s", hashAlg)
+	}
+	if !prehashed {
+		h := hash.New()
+		h.Write(input)
+		input = h.Sum(nil)
+	}
+	if len(input) != hash.Size() {
+		return nil, fmt.Errorf("invalid %s digest length %d", hashAlg, len(input))
+	}
+
+	if _, ok := signer.(*rsa.PrivateKey); ok && sigAlg == "pss" {
+		opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto, Hash: hash}
+		if saltLength == "hash" {
+			opts.SaltLength = rsa.PSSSaltLengthEqualsHash
+		}
+		return signer.Sign(rand.Reader, input, opts)
+	}
+
+	return signer.Sign(rand.Reader, input, hash)
+}
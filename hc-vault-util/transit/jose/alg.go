@@ -0,0 +1,96 @@
+package jose
+
+import (
+	"crypto"
+	"fmt"
+	"strings"
+)
+
+// JWS algorithms (RFC 7518, RFC 8037)
+const (
+	RS256 = "RS256"
+	RS384 = "RS384"
+	RS512 = "RS512"
+	PS256 = "PS256"
+	PS384 = "PS384"
+	PS512 = "PS512"
+	ES256 = "ES256"
+	ES384 = "ES384"
+	ES512 = "ES512"
+	EdDSA = "EdDSA"
+)
+
+// algorithms hash and transit signature_algorithm of JWS algorithms
+var algorithms = map[string]struct {
+	hash crypto.Hash
+	// transit signature_algorithm for rsa keys
+	sigAlg string
+}{
+	RS256: {crypto.SHA256, "pkcs1v15"},
+	RS384: {crypto.SHA384, "pkcs1v15"},
+	RS512: {crypto.SHA512, "pkcs1v15"},
+	PS256: {crypto.SHA256, "pss"},
+	PS384: {crypto.SHA384, "pss"},
+	PS512: {crypto.SHA512, "pss"},
+	ES256: {crypto.SHA256, ""},
+	ES384: {crypto.SHA384, ""},
+	ES512: {crypto.SHA512, ""},
+	// EdDSA signs the whole input
+	EdDSA: {crypto.Hash(0), ""},
+}
+
+// DefaultAlgorithm returns the JWS algorithm for the transit key type
+func DefaultAlgorithm(keyType string) (string, error) {
+
+	switch {
+	case strings.HasPrefix(keyType, "rsa-"):
+		return RS256, nil
+	case keyType == "ecdsa-p256":
+		return ES256, nil
+	case keyType == "ecdsa-p384":
+		return ES384, nil
+	case keyType == "ecdsa-p521":
+		return ES512, nil
+	case keyType == "ed25519":
+		return EdDSA, nil
+	}
+
+	return "", fmt.Errorf("unsupported transit key type '%s' for JWS", keyType)
+}
+
+// CheckAlgorithm returns an error if the JWS algorithm cannot be used
+// with the transit key type
+func CheckAlgorithm(alg, keyType string) error {
+
+	if _, ok := algorithms[alg]; !ok {
+		return fmt.Errorf("unsupported JWS algorithm '%s'", alg)
+	}
+
+	// RSA keys support all RS* and PS* algorithms
+	if strings.HasPrefix(keyType, "rsa-") && (strings.HasPrefix(alg, "RS") || strings.HasPrefix(alg, "PS")) {
+		return nil
+	}
+
+	// other key types have a single algorithm
+	defaultAlg, err := DefaultAlgorithm(keyType)
+	if err != nil {
+		return err
+	}
+
+	if alg != defaultAlg {
+		return fmt.Errorf("JWS algorithm '%s' is not supported by transit key type '%s'", alg, keyType)
+	}
+
+	return nil
+}
+
+// HashFunc returns the hash of the JWS algorithm, or 0 for EdDSA
+func HashFunc(alg string) crypto.Hash {
+	return algorithms[alg].hash
+}
+
+// TransitSignatureAlgorithm returns the transit signature_algorithm of
+// the JWS algorithm ('pkcs1v15' or 'pss' for RSA, empty otherwise)
+func TransitSignatureAlgorithm(alg string) string {
+	return algorithms[alg].sigAlg
+}
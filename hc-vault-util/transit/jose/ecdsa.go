@@ -0,0 +1,66 @@
+package jose
+
+import (
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+)
+
+// ecdsaSignature ASN.1 ECDSA signature
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// curveSizes ECDSA curve size in bytes of JWS algorithms
+var curveSizes = map[string]int{
+	ES256: 32,
+	ES384: 48,
+	ES512: 66,
+}
+
+// ASN1ToRaw converts the ASN.1 ECDSA signature from transit to the
+// fixed size R||S form of the JWS algorithm (RFC 7518 section 3.4)
+func ASN1ToRaw(alg string, sig []byte) ([]byte, error) {
+
+	size, ok := curveSizes[alg]
+	if !ok {
+		return nil, fmt.Errorf("not an ECDSA JWS algorithm '%s'", alg)
+	}
+
+	var s ecdsaSignature
+	rest, err := asn1.Unmarshal(sig, &s)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("trailing data after ECDSA signature")
+	}
+
+	if s.R.Sign() <= 0 || s.S.Sign() <= 0 || s.R.BitLen() > size*8 || s.S.BitLen() > size*8 {
+		return nil, fmt.Errorf("invalid ECDSA signature for '%s'", alg)
+	}
+
+	raw := make([]byte, 2*size)
+	s.R.FillBytes(raw[:size])
+	s.S.FillBytes(raw[size:])
+
+	return raw, nil
+}
+
+// RawToASN1 converts the R||S JWS ECDSA signature to ASN.1
+func RawToASN1(alg string, sig []byte) ([]byte, error) {
+
+	size, ok := curveSizes[alg]
+	if !ok {
+		return nil, fmt.Errorf("not an ECDSA JWS algorithm '%s'", alg)
+	}
+
+	if len(sig) != 2*size {
+		return nil, fmt.Errorf("invalid ECDSA signature length %d for '%s'", len(sig), alg)
+	}
+
+	return asn1.Marshal(ecdsaSignature{
+		R: new(big.Int).SetBytes(sig[:size]),
+		S: new(big.Int).SetBytes(sig[size:]),
+	})
+}
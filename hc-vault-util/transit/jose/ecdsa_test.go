@@ -0,0 +1,215 @@
+package jose
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/asn1"
+	"encoding/base64"
+	"math/big"
+	"testing"
+)
+
+// RFC 7515 appendix A.3 (ES256) and A.4 (ES512) examples
+var rfc7515ECDSAExamples = []struct {
+	alg   string
+	curve elliptic.Curve
+	x, y  string
+	token string
+}{
+	{
+		alg:   ES256,
+		curve: elliptic.P256(),
+		x:     "f83OJ3D2xF1Bg8vub9tLe1gHMzV76e8Tus9uPHvRVEU",
+		y:     "x_FEzRu9m36HLN_tue659LNpXW6pCyStikYjKIWI5a0",
+		token: "eyJhbGciOiJFUzI1NiJ9" +
+			".eyJpc3MiOiJqb2UiLA0KICJleHAiOjEzMDA4MTkzODAsDQogImh0dHA6Ly9leGFtcGxlLmNvbS9pc19yb290Ijp0cnVlfQ" +
+			".DtEhU3ljbEg8L38VWAfUAqOyKAM6-Xx-F4GawxaepmXFCgfTjDxw5djxLa8ISlSApmWQxfKTUJqPP3-Kg6NU1Q",
+	},
+	{
+		alg:   ES512,
+		curve: elliptic.P521(),
+		x:     "AekpBQ8ST8a8VcfVOTNl353vSrDCLLJXmPk06wTjxrrjcBpXp5EOnYG_NjFZ6OvLFV1jSfS9tsz4qUxcWceqwQGk",
+		y:     "ADSmRA43Z1DSNx_RvcLI87cdL07l6jQyyBXMoxVg_l2Th-x3S1WDhjDly79ajL4Kkd0AZMaZmh9ubmf63e3kyMj2",
+		token: "eyJhbGciOiJFUzUxMiJ9" +
+			".UGF5bG9hZA" +
+			".AdwMgeerwtHoh-l192l60hp9wAHZFVJbLfD_UxMi70cwnZOYaRI1bKPWROc-mZZqwqT2SI-KGDKB34XO0aw_7XdtAG8GaSwFKdCAPZgoXD2YBJZCPEX3xKpRwcdOO8KpEHwJjyqOgzDO7iKvU8vcnwNrmxYbSW9ERBXukOXolLzeO_Jn",
+	},
+}
+
+func b64BigInt(t *testing.T, s string) *big.Int {
+	t.Helper()
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return new(big.Int).SetBytes(b)
+}
+
+func TestRFC7515ECDSAExamples(t *testing.T) {
+
+	for _, tt := range rfc7515ECDSAExamples {
+		t.Run(tt.alg, func(t *testing.T) {
+
+			pub := &ecdsa.PublicKey{Curve: tt.curve, X: b64BigInt(t, tt.x), Y: b64BigInt(t, tt.y)}
+
+			jws, err := Parse(tt.token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if jws.Header.Alg != tt.alg {
+				t.Fatalf("alg = %s, want %s", jws.Header.Alg, tt.alg)
+			}
+
+			err = Verify(pub, tt.alg, jws.SigningInput, jws.Signature)
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+
+			// R||S -> ASN.1 -> R||S
+			der, err := RawToASN1(tt.alg, jws.Signature)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ecdsa.VerifyASN1(pub, Digest(tt.alg, jws.SigningInput), der) {
+				t.Error("ASN.1 signature does not verify")
+			}
+
+			raw, err := ASN1ToRaw(tt.alg, der)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(raw, jws.Signature) {
+				t.Errorf("ASN1ToRaw = %x, want %x", raw, jws.Signature)
+			}
+
+			// tampered signing input
+			err = Verify(pub, tt.alg, append(jws.SigningInput, 'x'), jws.Signature)
+			if err == nil {
+				t.Error("Verify of tampered signing input succeeded")
+			}
+		})
+	}
+}
+
+func TestASN1ToRaw(t *testing.T) {
+
+	curves := map[string]elliptic.Curve{
+		ES256: elliptic.P256(),
+		ES384: elliptic.P384(),
+		ES512: elliptic.P521(),
+	}
+
+	for alg, curve := range curves {
+		t.Run(alg, func(t *testing.T) {
+
+			priv, err := ecdsa.GenerateKey(curve, rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			signingInput := []byte("eyJhbGciOiJFUzI1NiJ9.UGF5bG9hZA")
+
+			// transit returns ASN.1 signatures
+			der, err := ecdsa.SignASN1(rand.Reader, priv, Digest(alg, signingInput))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			raw, err := ASN1ToRaw(alg, der)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(raw) != 2*curveSizes[alg] {
+				t.Fatalf("raw signature length = %d, want %d", len(raw), 2*curveSizes[alg])
+			}
+
+			err = Verify(&priv.PublicKey, alg, signingInput, raw)
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+		})
+	}
+}
+
+func TestASN1ToRawPadding(t *testing.T) {
+
+	// R and S shorter than the curve size are left padded with zeros
+	der, err := asn1.Marshal(ecdsaSignature{R: big.NewInt(1), S: big.NewInt(0x0102)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := ASN1ToRaw(ES256, der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := make([]byte, 64)
+	want[31] = 0x01
+	want[62] = 0x01
+	want[63] = 0x02
+	if !bytes.Equal(raw, want) {
+		t.Errorf("ASN1ToRaw = %x, want %x", raw, want)
+	}
+}
+
+func TestASN1ToRawInvalid(t *testing.T) {
+
+	tooLarge := new(big.Int).Lsh(big.NewInt(1), 256)
+	valid, _ := asn1.Marshal(ecdsaSignature{R: big.NewInt(1), S: big.NewInt(1)})
+
+	tests := []struct {
+		name string
+		alg  string
+		sig  []byte
+	}{
+		{name: "not ECDSA", alg: RS256, sig: valid},
+		{name: "not ASN.1", alg: ES256, sig: []byte("signature")},
+		{name: "trailing data", alg: ES256, sig: append(valid, 0)},
+		{name: "R too large", alg: ES256, sig: mustMarshal(t, ecdsaSignature{R: tooLarge, S: big.NewInt(1)})},
+		{name: "S too large", alg: ES256, sig: mustMarshal(t, ecdsaSignature{R: big.NewInt(1), S: tooLarge})},
+		{name: "zero R", alg: ES256, sig: mustMarshal(t, ecdsaSignature{R: big.NewInt(0), S: big.NewInt(1)})},
+		{name: "negative S", alg: ES256, sig: mustMarshal(t, ecdsaSignature{R: big.NewInt(1), S: big.NewInt(-1)})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ASN1ToRaw(tt.alg, tt.sig)
+			if err == nil {
+				t.Error("ASN1ToRaw succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestRawToASN1Invalid(t *testing.T) {
+
+	for alg, size := range curveSizes {
+		for _, n := range []int{0, 2*size - 1, 2*size + 1} {
+			_, err := RawToASN1(alg, make([]byte, n))
+			if err == nil {
+				t.Errorf("RawToASN1(%s) of %d bytes succeeded, want error", alg, n)
+			}
+		}
+	}
+
+	_, err := RawToASN1(PS256, make([]byte, 64))
+	if err == nil {
+		t.Error("RawToASN1(PS256) succeeded, want error")
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	t.Helper()
+
+	b, err := asn1.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
@@ -0,0 +1,192 @@
+package jose
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Header JOSE header of a JWS
+type Header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// JWS compact serialized JWS
+type JWS struct {
+	Header    Header
+	Payload   []byte
+	Signature []byte
+	// base64url header '.' base64url payload
+	SigningInput []byte
+}
+
+// KeyID returns the 'kid' of version of the transit key name
+func KeyID(name string, version int) string {
+	return fmt.Sprintf("%s:v%d", name, version)
+}
+
+// ParseKeyID returns the transit key name and version of the 'kid'
+func ParseKeyID(kid string) (string, int, error) {
+
+	i := strings.LastIndex(kid, ":v")
+	if i <= 0 {
+		return "", 0, fmt.Errorf("invalid kid '%s', expecting '<name>:v<version>'", kid)
+	}
+
+	version, err := strconv.Atoi(kid[i+2:])
+	if err != nil || version <= 0 {
+		return "", 0, fmt.Errorf("invalid kid '%s', expecting '<name>:v<version>'", kid)
+	}
+
+	return kid[:i], version, nil
+}
+
+// SigningInput returns the JWS signing input of header and payload
+func SigningInput(header Header, payload []byte) ([]byte, error) {
+
+	h, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(payload)), nil
+}
+
+// Encode returns the compact serialization of the signed JWS
+func Encode(signingInput, signature []byte) string {
+	return string(signingInput) + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// Parse parses the compact serialized JWS token
+func Parse(token string) (*JWS, error) {
+
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid JWS, expecting 3 parts but got %d", len(parts))
+	}
+
+	rawHeader, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWS header: %w", err)
+	}
+
+	jws := &JWS{
+		SigningInput: []byte(parts[0] + "." + parts[1]),
+	}
+
+	err = json.Unmarshal(rawHeader, &jws.Header)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWS header: %w", err)
+	}
+
+	jws.Payload, err = base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWS payload: %w", err)
+	}
+
+	jws.Signature, err = base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWS signature: %w", err)
+	}
+
+	return jws, nil
+}
+
+// Digest returns the hash of the signing input for the JWS algorithm,
+// or the signing input itself for EdDSA
+func Digest(alg string, signingInput []byte) []byte {
+
+	hash := HashFunc(alg)
+	if hash == crypto.Hash(0) {
+		return signingInput
+	}
+
+	h := hash.New()
+	h.Write(signingInput)
+	return h.Sum(nil)
+}
+
+// Verify verifies the JWS signature of the signing input with the public key
+func Verify(pub crypto.PublicKey, alg string, signingInput, signature []byte) error {
+
+	if _, ok := algorithms[alg]; !ok {
+		return fmt.Errorf("unsupported JWS algorithm '%s'", alg)
+	}
+
+	digest := Digest(alg, signingInput)
+	valid := false
+
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		switch TransitSignatureAlgorithm(alg) {
+		case "pkcs1v15":
+			valid = rsa.VerifyPKCS1v15(pub, HashFunc(alg), digest, signature) == nil
+		case "pss":
+			valid = rsa.VerifyPSS(pub, HashFunc(alg), digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		}
+
+	case *ecdsa.PublicKey:
+		der, err := RawToASN1(alg, signature)
+		if err != nil {
+			return err
+		}
+		valid = ecdsa.VerifyASN1(pub, digest, der)
+
+	case ed25519.PublicKey:
+		valid = alg == EdDSA && ed25519.Verify(pub, signingInput, signature)
+
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+
+	if !valid {
+		return fmt.Errorf("invalid JWS signature")
+	}
+
+	return nil
+}
+
+// CheckTimeClaims returns an error if the JWT claims 'exp' or 'nbf' are not valid at now
+func CheckTimeClaims(payload []byte, now time.Time) error {
+
+	var claims struct {
+		Exp *json.Number `json:"exp"`
+		Nbf *json.Number `json:"nbf"`
+	}
+
+	err := json.Unmarshal(payload, &claims)
+	if err != nil {
+		return fmt.Errorf("invalid JWT claims: %w", err)
+	}
+
+	if claims.Exp != nil {
+		exp, err := claims.Exp.Float64()
+		if err != nil {
+			return fmt.Errorf("invalid 'exp' claim: %w", err)
+		}
+		if now.Unix() >= int64(exp) {
+			return fmt.Errorf("token expired at %s", time.Unix(int64(exp), 0).UTC().Format(time.RFC3339))
+		}
+	}
+
+	if claims.Nbf != nil {
+		nbf, err := claims.Nbf.Float64()
+		if err != nil {
+			return fmt.Errorf("invalid 'nbf' claim: %w", err)
+		}
+		if now.Unix() < int64(nbf) {
+			return fmt.Errorf("token not valid before %s", time.Unix(int64(nbf), 0).UTC().Format(time.RFC3339))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package jose
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+)
+
+// RFC 7518 section 3.5: the PSS salt size is the size of the hash output
+func TestVerifyPSSSaltLength(t *testing.T) {
+
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, alg := range []string{PS256, PS384, PS512} {
+		t.Run(alg, func(t *testing.T) {
+
+			signingInput, err := SigningInput(Header{Alg: alg}, []byte("Payload"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			digest := Digest(alg, signingInput)
+
+			sig, err := rsa.SignPSS(rand.Reader, priv, HashFunc(alg), digest, &rsa.PSSOptions{SaltLength: HashFunc(alg).Size()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = Verify(&priv.PublicKey, alg, signingInput, sig)
+			if err != nil {
+				t.Fatalf("Verify with salt length %d: %v", HashFunc(alg).Size(), err)
+			}
+
+			// e.g. transit 'auto' salt length, the maximum for the key
+			for _, saltLength := range []int{0, 20, rsa.PSSSaltLengthAuto} {
+				sig, err := rsa.SignPSS(rand.Reader, priv, HashFunc(alg), digest, &rsa.PSSOptions{SaltLength: saltLength})
+				if err != nil {
+					t.Fatal(err)
+				}
+				err = Verify(&priv.PublicKey, alg, signingInput, sig)
+				if err == nil {
+					t.Errorf("Verify with salt length %d succeeded, want error", saltLength)
+				}
+			}
+
+			// RS* signature
+			sig, err = rsa.SignPKCS1v15(rand.Reader, priv, HashFunc(alg), digest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = Verify(&priv.PublicKey, alg, signingInput, sig)
+			if err == nil {
+				t.Error("Verify of PKCS1v15 signature succeeded, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package transit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/jose"
+)
+
+// JWKSKey is a transit key published in a JWKS
+type JWKSKey struct {
+	Mount string
+	Name  string
+	// JWS algorithm, or default for the transit key type if empty
+	Alg string
+}
+
+// ParseJWKSKey parses a '[mount/]name[=alg]' JWKS key reference, with
+// defaultMount if the mount is not set
+func ParseJWKSKey(ref, defaultMount string) (JWKSKey, error) {
+
+	k := JWKSKey{Mount: defaultMount}
+
+	path := ref
+	if i := strings.LastIndex(ref, "="); i >= 0 {
+		path = ref[:i]
+		k.Alg = ref[i+1:]
+	}
+
+	// mount path may be nested
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		k.Mount = path[:i]
+		path = path[i+1:]
+	}
+	k.Name = path
+
+	if k.Mount == "" || k.Name == "" {
+		return JWKSKey{}, fmt.Errorf("invalid key '%s', expecting '[mount/]name[=alg]'", ref)
+	}
+
+	return k, nil
+}
+
+// ExportJWKS writes the JWKS of every version of the transit keys to outFile (or stdout)
+func (t *TransitClient) ExportJWKS(keys []JWKSKey, outFile string) error {
+
+	jwks, err := t.JWKS(keys)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(jwks, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	t.logger.Info("JWKS exported", "keys", len(jwks.Keys))
+
+	return writeOutput(outFile, append(data, '\n'), 0644)
+}
+
+// JWKS returns the JWKS of the public keys from min_decryption_version to
+// latest_version of the transit keys, with 'kid' '<name>:v<version>'
+func (t *TransitClient) JWKS(keys []JWKSKey) (*jose.JWKS, error) {
+
+	jwks := &jose.JWKS{Keys: []*jose.JWK{}}
+	kids := map[string]string{}
+	for _, ref := range keys {
+
+		t.SetKeyProperties(ref.Mount, ref.Name)
+		k, err := t.syncTransitKey()
+		if err != nil {
+			t.logger.Error("Error reading transit key", "path", fmt.Sprintf("%s/keys/%s", ref.Mount, ref.Name), "error", err)
+			return nil, err
+		}
+
+		alg, err := jwsAlgorithm(k, ref.Alg)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pub := range k.PublicKeys {
+			kid := jose.KeyID(k.Name, pub.Version)
+			// kid does not include the mount
+			if mount, ok := kids[kid]; ok {
+				return nil, fmt.Errorf("duplicate kid '%s' for mounts '%s' and '%s'", kid, mount, ref.Mount)
+			}
+			kids[kid] = ref.Mount
+
+			jwk, err := jose.NewJWK(pub.PublicKey, kid, alg, "sig")
+			if err != nil {
+				return nil, err
+			}
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+	}
+
+	return jwks, nil
+}
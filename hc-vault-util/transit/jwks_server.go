@@ -0,0 +1,219 @@
+package transit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JWKSPath is the path of the JWKS served by ServeJWKS
+const JWKSPath = "/.well-known/jwks.json"
+
+// HealthPath is the path of the health endpoint served by ServeJWKS
+const HealthPath = "/healthz"
+
+// time allowed to in-flight requests on shutdown
+const jwksShutdownTimeout = 10 * time.Second
+
+// JWKSServerOptions are the options of the JWKS HTTP server
+type JWKSServerOptions struct {
+	// listen address (e.g. ':8080')
+	Addr string
+	// TLS certificate and key files, plain HTTP if empty
+	TLSCertFile string
+	TLSKeyFile  string
+	// interval between syncs of the transit keys
+	RefreshInterval time.Duration
+	// Cache-Control max-age of the JWKS
+	MaxAge time.Duration
+}
+
+// jwksServer serves the last JWKS successfully synced from transit
+type jwksServer struct {
+	mu sync.RWMutex
+	// marshalled JWKS
+	body []byte
+	etag string
+	// time of the last successful sync
+	lastSync time.Time
+	// error of the last sync, if any
+	lastErr error
+	maxAge  time.Duration
+}
+
+// ServeJWKS serves the JWKS of the transit keys on JWKSPath and a health endpoint
+// on HealthPath, syncs the transit keys every opts.RefreshInterval, and shuts down
+// gracefully when ctx is done
+func (t *TransitClient) ServeJWKS(ctx context.Context, keys []JWKSKey, opts JWKSServerOptions) error {
+
+	s := &jwksServer{maxAge: opts.MaxAge}
+
+	// do not start without keys
+	err := s.refresh(t, keys)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(JWKSPath, s.handleJWKS)
+	mux.HandleFunc(HealthPath, s.handleHealth)
+
+	srv := &http.Server{
+		Addr:              opts.Addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+
+		ticker := time.NewTicker(opts.RefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), jwksShutdownTimeout)
+				defer cancel()
+
+				t.logger.Info("Shutting down JWKS server")
+				err := srv.Shutdown(shutdownCtx)
+				if err != nil {
+					t.logger.Error("Error shutting down JWKS server", "error", err)
+				}
+				return
+
+			case <-ticker.C:
+				err := s.refresh(t, keys)
+				if err != nil {
+					// keep serving the last synced JWKS
+					t.logger.Error("Error refreshing JWKS, serving last synced keys", "last_sync", s.lastSync, "error", err)
+				}
+			}
+		}
+	}()
+
+	t.logger.Info("Serving JWKS", "addr", opts.Addr, "path", JWKSPath, "refresh_interval", opts.RefreshInterval)
+
+	if opts.TLSCertFile != "" {
+		err = srv.ListenAndServeTLS(opts.TLSCertFile, opts.TLSKeyFile)
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		// wait for in-flight requests
+		<-shutdownDone
+		return nil
+	}
+
+	return err
+}
+
+// refresh syncs the transit keys and updates the served JWKS
+func (s *jwksServer) refresh(t *TransitClient, keys []JWKSKey) error {
+
+	jwks, err := t.JWKS(keys)
+	if err == nil {
+		var body []byte
+		body, err = json.Marshal(jwks)
+		if err == nil {
+			sum := sha256.Sum256(body)
+
+			s.mu.Lock()
+			if s.etag != etag(sum[:]) {
+				t.logger.Info("JWKS updated", "keys", len(jwks.Keys))
+			}
+			s.body = body
+			s.etag = etag(sum[:])
+			s.lastSync = time.Now()
+			s.lastErr = nil
+			s.mu.Unlock()
+
+			return nil
+		}
+	}
+
+	s.mu.Lock()
+	s.lastErr = err
+	s.mu.Unlock()
+
+	return err
+}
+
+// handleJWKS serves the JWKS with cache headers
+func (s *jwksServer) handleJWKS(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mu.RLock()
+	body, tag := s.body, s.etag
+	s.mu.RUnlock()
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(s.maxAge.Seconds())))
+	w.Header().Set("ETag", tag)
+
+	if etagMatch(r.Header.Get("If-None-Match"), tag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		//nolint
+		w.Write(body)
+	}
+}
+
+// handleHealth returns 200 if the last sync was successful, 503 otherwise
+func (s *jwksServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+
+	s.mu.RLock()
+	status := map[string]interface{}{
+		"status":    "ok",
+		"last_sync": s.lastSync.UTC().Format(time.RFC3339),
+	}
+	code := http.StatusOK
+	if s.lastErr != nil {
+		status["status"] = "degraded"
+		status["error"] = s.lastErr.Error()
+		code = http.StatusServiceUnavailable
+	}
+	s.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	//nolint
+	json.NewEncoder(w).Encode(status)
+}
+
+// etag returns the strong ETag of the hash
+func etag(sum []byte) string {
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatch returns true if the If-None-Match header matches tag
+func etagMatch(ifNoneMatch, tag string) bool {
+
+	for _, t := range strings.Split(ifNoneMatch, ",") {
+		t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+		if t == "*" || t == tag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,279 @@
+package transit
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/internal/transittest"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/jose"
+)
+
+// getJWKS returns the response of the JWKS handler to a method request with
+// the If-None-Match header ifNoneMatch if not empty
+func getJWKS(s *jwksServer, method, ifNoneMatch string) *httptest.ResponseRecorder {
+
+	req := httptest.NewRequest(method, JWKSPath, nil)
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleJWKS(rec, req)
+	return rec
+}
+
+// getHealth returns the response code and status of the health handler
+func getHealth(t *testing.T, s *jwksServer) (int, map[string]interface{}) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	s.handleHealth(rec, httptest.NewRequest(http.MethodGet, HealthPath, nil))
+
+	if cc := rec.Header().Get("Cache-Control"); cc != "no-store" {
+		t.Errorf("health Cache-Control = %q, want no-store", cc)
+	}
+
+	var status map[string]interface{}
+	err := json.Unmarshal(rec.Body.Bytes(), &status)
+	if err != nil {
+		t.Fatalf("invalid health response %q: %v", rec.Body.String(), err)
+	}
+
+	return rec.Code, status
+}
+
+// jwksKids returns the kids of the JWKS body
+func jwksKids(t *testing.T, body []byte) []string {
+	t.Helper()
+
+	var jwks jose.JWKS
+	err := json.Unmarshal(body, &jwks)
+	if err != nil {
+		t.Fatalf("invalid JWKS %q: %v", body, err)
+	}
+
+	var kids []string
+	for _, k := range jwks.Keys {
+		kids = append(kids, k.Kid)
+	}
+	return kids
+}
+
+func TestJWKSServer(t *testing.T) {
+
+	srv := transittest.NewServer(t, map[string]*transittest.Key{
+		"sig": transittest.GenerateKey(t, "ecdsa-p256", 2),
+	})
+	tc := newTestServerClient(srv, "sig")
+	keys := []JWKSKey{{Mount: transittest.Mount, Name: "sig"}}
+
+	s := &jwksServer{maxAge: 5 * time.Minute}
+	err := s.refresh(tc, keys)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	rec := getJWKS(s, http.MethodGet, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET status = %d, want 200", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "public, max-age=300" {
+		t.Errorf("Cache-Control = %q, want public, max-age=300", cc)
+	}
+	tag := rec.Header().Get("ETag")
+	if tag == "" {
+		t.Fatal("missing ETag")
+	}
+	if kids := jwksKids(t, rec.Body.Bytes()); len(kids) != 2 || kids[0] != "sig:v1" || kids[1] != "sig:v2" {
+		t.Errorf("kids = %v, want [sig:v1 sig:v2]", kids)
+	}
+	body := rec.Body.String()
+
+	// conditional requests
+	for _, ifNoneMatch := range []string{tag, "W/" + tag, `"other", ` + tag, "*"} {
+		rec = getJWKS(s, http.MethodGet, ifNoneMatch)
+		if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s: status = %d with %d bytes, want 304 without body", ifNoneMatch, rec.Code, rec.Body.Len())
+		}
+		if rec.Header().Get("ETag") != tag || rec.Header().Get("Cache-Control") == "" {
+			t.Errorf("If-None-Match %s: missing ETag or Cache-Control on 304", ifNoneMatch)
+		}
+	}
+	rec = getJWKS(s, http.MethodGet, `"other"`)
+	if rec.Code != http.StatusOK || rec.Body.String() != body {
+		t.Errorf("If-None-Match other: status = %d, want 200 with the JWKS", rec.Code)
+	}
+
+	rec = getJWKS(s, http.MethodHead, "")
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Errorf("HEAD status = %d with %d bytes, want 200 without body", rec.Code, rec.Body.Len())
+	}
+
+	rec = getJWKS(s, http.MethodPost, "")
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "GET, HEAD" {
+		t.Errorf("POST status = %d, Allow %q, want 405 with GET, HEAD", rec.Code, rec.Header().Get("Allow"))
+	}
+
+	code, status := getHealth(t, s)
+	if code != http.StatusOK || status["status"] != "ok" {
+		t.Errorf("health = %d %v, want 200 ok", code, status)
+	}
+
+	// rotated key
+	srv.SetKey("sig", transittest.GenerateKey(t, "ecdsa-p256", 3))
+	err = s.refresh(tc, keys)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	rec = getJWKS(s, http.MethodGet, tag)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("If-None-Match of the previous JWKS: status = %d, want 200", rec.Code)
+	}
+	if rec.Header().Get("ETag") == tag {
+		t.Error("ETag not changed by the rotated key")
+	}
+	if kids := jwksKids(t, rec.Body.Bytes()); len(kids) != 3 {
+		t.Errorf("kids = %v, want 3 versions", kids)
+	}
+	tag = rec.Header().Get("ETag")
+	body = rec.Body.String()
+
+	// failed refresh keeps the last good JWKS
+	srv.DeleteKey("sig")
+	err = s.refresh(tc, keys)
+	if err == nil {
+		t.Fatal("refresh of a deleted key succeeded")
+	}
+
+	code, status = getHealth(t, s)
+	if code != http.StatusServiceUnavailable || status["status"] != "degraded" || status["error"] == nil {
+		t.Errorf("health = %d %v, want 503 degraded with error", code, status)
+	}
+
+	rec = getJWKS(s, http.MethodGet, "")
+	if rec.Code != http.StatusOK || rec.Body.String() != body || rec.Header().Get("ETag") != tag {
+		t.Errorf("GET after failed refresh: status = %d, want 200 with the last JWKS", rec.Code)
+	}
+
+	// recovered
+	srv.SetKey("sig", transittest.GenerateKey(t, "ecdsa-p256", 1))
+	err = s.refresh(tc, keys)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	code, status = getHealth(t, s)
+	if code != http.StatusOK || status["status"] != "ok" {
+		t.Errorf("health after recovery = %d %v, want 200 ok", code, status)
+	}
+}
+
+func TestEtagMatch(t *testing.T) {
+
+	tag := `"abc"`
+	tests := []struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		{"", false},
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"x", "abc"`, true},
+		{`"x","y"`, false},
+		{"*", true},
+		{`abc`, false},
+	}
+
+	for _, tc := range tests {
+		if got := etagMatch(tc.ifNoneMatch, tag); got != tc.want {
+			t.Errorf("etagMatch(%q) = %t, want %t", tc.ifNoneMatch, got, tc.want)
+		}
+	}
+}
+
+func TestServeJWKS(t *testing.T) {
+
+	srv := transittest.NewServer(t, map[string]*transittest.Key{
+		"sig": transittest.GenerateKey(t, "ed25519", 1),
+	})
+	tc := newTestServerClient(srv, "sig")
+
+	// free port
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- tc.ServeJWKS(ctx, []JWKSKey{{Mount: transittest.Mount, Name: "sig"}}, JWKSServerOptions{
+			Addr:            addr,
+			RefreshInterval: time.Hour,
+			MaxAge:          time.Minute,
+		})
+	}()
+
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		resp, err = http.Get("http://" + addr + JWKSPath)
+		if err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("GET %s: %v", JWKSPath, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Cache-Control") != "public, max-age=60" {
+		t.Errorf("GET status = %d, Cache-Control %q", resp.StatusCode, resp.Header.Get("Cache-Control"))
+	}
+
+	resp, err = http.Get("http://" + addr + HealthPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("health status = %d, want 200", resp.StatusCode)
+	}
+
+	// graceful shutdown, without waiting for the client connections
+	http.DefaultClient.CloseIdleConnections()
+	cancel()
+	select {
+	case err = <-done:
+		if err != nil {
+			t.Errorf("ServeJWKS: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ServeJWKS did not shut down")
+	}
+}
+
+func TestServeJWKSMissingKey(t *testing.T) {
+
+	srv := transittest.NewServer(t, map[string]*transittest.Key{})
+	tc := newTestServerClient(srv, "missing")
+
+	// does not start without keys
+	err := tc.ServeJWKS(context.Background(), []JWKSKey{{Mount: transittest.Mount, Name: "missing"}}, JWKSServerOptions{
+		Addr:            "127.0.0.1:0",
+		RefreshInterval: time.Hour,
+	})
+	if err == nil {
+		t.Fatal("ServeJWKS of a missing key succeeded")
+	}
+}
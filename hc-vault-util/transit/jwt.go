@@ -0,0 +1,242 @@
+package transit
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/jose"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/key"
+)
+
+// JWTOptions are the options of a JWT signed with a transit key
+type JWTOptions struct {
+	// JWS algorithm, or default for the transit key type if empty
+	Alg string
+	// 'typ' header
+	Typ string
+	// if not 0, set 'iat' and 'exp' claims
+	ExpiresIn time.Duration
+}
+
+// SignJWT signs the JSON claims from claimsFile (or stdin) with version keyVersion
+// (or latest if 0) of the transit key, and writes the compact JWT to outFile (or stdout)
+func (t *TransitClient) SignJWT(claimsFile string, keyVersion int, opts JWTOptions, outFile string) error {
+
+	claims, err := readInput(claimsFile)
+	if err != nil {
+		t.logger.Error("Error reading claims", "error", err)
+		return err
+	}
+
+	token, err := t.signJWT(claims, keyVersion, opts)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(outFile, []byte(token+"\n"), 0600)
+}
+
+// signJWT returns the compact JWT of the JSON claims signed with the transit key
+func (t *TransitClient) signJWT(claims []byte, keyVersion int, opts JWTOptions) (string, error) {
+
+	payload, err := jwtPayload(claims, opts.ExpiresIn)
+	if err != nil {
+		t.logger.Error("Invalid claims", "error", err)
+		return "", err
+	}
+
+	signer, err := t.newTransitSigner(keyVersion)
+	if err != nil {
+		return "", err
+	}
+
+	alg, err := jwsAlgorithm(signer.Key, opts.Alg)
+	if err != nil {
+		return "", err
+	}
+	setJWSSaltLength(signer.Key, alg)
+	signer.SigAlg = jose.TransitSignatureAlgorithm(alg)
+
+	header := jose.Header{
+		Alg: alg,
+		Typ: opts.Typ,
+		Kid: jose.KeyID(signer.Key.Name, signer.Version),
+	}
+
+	signingInput, err := jose.SigningInput(header, payload)
+	if err != nil {
+		return "", err
+	}
+
+	// EdDSA signs the whole signing input
+	sig, err := signer.Sign(rand.Reader, jose.Digest(alg, signingInput), jose.HashFunc(alg))
+	if err != nil {
+		return "", err
+	}
+
+	// transit ECDSA signatures are ASN.1, JWS expects R||S
+	if strings.HasPrefix(alg, "ES") {
+		sig, err = jose.ASN1ToRaw(alg, sig)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	t.logger.Info("JWT signed", "alg", alg, "kid", header.Kid)
+
+	return jose.Encode(signingInput, sig), nil
+}
+
+// VerifyJWT verifies the compact JWT from tokenFile (or stdin) with the transit key,
+// locally with the transit public keys or with transit verify if viaTransit, and
+// writes its claims to stdout.
+//
+// The 'exp' and 'nbf' claims are checked unless ignoreTimeClaims.
+func (t *TransitClient) VerifyJWT(tokenFile string, viaTransit, ignoreTimeClaims bool) error {
+
+	token, err := readInput(tokenFile)
+	if err != nil {
+		t.logger.Error("Error reading token", "error", err)
+		return err
+	}
+
+	jws, err := jose.Parse(string(token))
+	if err != nil {
+		return err
+	}
+
+	err = t.verifyJWS(jws, viaTransit)
+	if err != nil {
+		return err
+	}
+
+	if !ignoreTimeClaims {
+		err = jose.CheckTimeClaims(jws.Payload, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+
+	t.logger.Info("JWT signature valid", "alg", jws.Header.Alg, "kid", jws.Header.Kid)
+
+	return writeOutput("", append(jws.Payload, '\n'), 0)
+}
+
+// verifyJWS verifies the JWS signature with the transit key version from its 'kid'
+func (t *TransitClient) verifyJWS(jws *jose.JWS, viaTransit bool) error {
+
+	name, version, err := jose.ParseKeyID(jws.Header.Kid)
+	if err != nil {
+		return err
+	}
+	if name != t.keyName {
+		return fmt.Errorf("JWT kid '%s' is not for transit key '%s'", jws.Header.Kid, t.keyName)
+	}
+
+	k, err := t.syncTransitKey()
+	if err != nil {
+		return err
+	}
+
+	err = jose.CheckAlgorithm(jws.Header.Alg, k.Type)
+	if err != nil {
+		return err
+	}
+
+	if !viaTransit {
+		for _, pub := range k.PublicKeys {
+			if pub.Version == version {
+				return jose.Verify(pub.PublicKey, jws.Header.Alg, jws.SigningInput, jws.Signature)
+			}
+		}
+		return &key.KeyVersionError{Name: k.Name, Version: version, MinVersion: k.MinVersion, LatestVersion: k.Version}
+	}
+
+	return transitVerifyJWS(k, version, jws)
+}
+
+// transitVerifyJWS verifies the JWS signature with transit verify
+func transitVerifyJWS(k *key.VaultTransitKey, version int, jws *jose.JWS) error {
+
+	alg := jws.Header.Alg
+	setJWSSaltLength(k, alg)
+	k.SetSigKeyVersion(version)
+
+	sig := jws.Signature
+	if strings.HasPrefix(alg, "ES") {
+		var err error
+		sig, err = jose.RawToASN1(alg, sig)
+		if err != nil {
+			return err
+		}
+	}
+
+	// EdDSA verifies the whole signing input
+	hashAlg := "sha2-256"
+	prehashed := false
+	if hash := jose.HashFunc(alg); hash != crypto.Hash(0) {
+		var err error
+		hashAlg, err = key.CryptoHashToVaultHash(hash)
+		if err != nil {
+			return err
+		}
+		prehashed = true
+	}
+
+	valid, err := k.Verify(jose.Digest(alg, jws.SigningInput), base64.StdEncoding.EncodeToString(sig), jose.TransitSignatureAlgorithm(alg), hashAlg, "asn1", prehashed)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return fmt.Errorf("invalid JWS signature")
+	}
+
+	return nil
+}
+
+// jwsAlgorithm returns alg, or the default JWS algorithm of the transit key type
+// if alg is empty
+func jwsAlgorithm(k *key.VaultTransitKey, alg string) (string, error) {
+
+	if alg == "" {
+		return jose.DefaultAlgorithm(k.Type)
+	}
+
+	return alg, jose.CheckAlgorithm(alg, k.Type)
+}
+
+// setJWSSaltLength sets the PSS salt length required by PS* algorithms
+func setJWSSaltLength(k *key.VaultTransitKey, alg string) {
+
+	if jose.TransitSignatureAlgorithm(alg) == "pss" {
+		// RFC 7518 section 3.5: salt size must be the hash size
+		k.SetSaltLength("hash")
+	}
+}
+
+// jwtPayload returns the compact JSON claims, with 'iat' and 'exp' if expiresIn is not 0
+func jwtPayload(claims []byte, expiresIn time.Duration) ([]byte, error) {
+
+	dec := json.NewDecoder(bytes.NewReader(claims))
+	dec.UseNumber()
+
+	c := map[string]interface{}{}
+	err := dec.Decode(&c)
+	if err != nil {
+		return nil, fmt.Errorf("claims must be a JSON object: %w", err)
+	}
+
+	if expiresIn != 0 {
+		now := time.Now()
+		c["iat"] = now.Unix()
+		c["exp"] = now.Add(expiresIn).Unix()
+	}
+
+	return json.Marshal(c)
+}
@@ -3,12 +3,15 @@ package key
 import (
 	"context"
 	"crypto"
+	"crypto/ed25519"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
 	vault "github.com/hashicorp/vault/api"
 	"github.com/savaki/jq"
@@ -37,6 +40,9 @@ type TransitPublicKey struct {
 
 	// Name
 	Name string
+
+	// creation_time of the key version
+	CreationTime time.Time
 }
 
 func NewTransitPublicKey(pub crypto.PublicKey, v int, name string) *TransitPublicKey {
@@ -64,6 +70,8 @@ type VaultTransitKey struct {
 
 	// Set sig version
 	SigVersion int
+	// salt_length of 'pss' signature_algorithm, or empty for transit default
+	SaltLength string
 
 	// List of public keys
 	PublicKeys []*TransitPublicKey
@@ -117,6 +125,9 @@ func (k *VaultTransitKey) SyncKeyInfo() error {
 		return fmt.Errorf("key type not found for %s", keyPath)
 	}
 
+	// public key encoding depends on the key type
+	k.Type = keyType
+
 	keyVersionJson, ok := keyInfo.Data["latest_version"].(json.Number)
 	if !ok {
 		k.logger.Debug("Key latest_version not found in transit read response", zap.Any("resp", keyInfo))
@@ -141,17 +152,28 @@ func (k *VaultTransitKey) SyncKeyInfo() error {
 
 	pubKeys := []*TransitPublicKey{}
 
-	// for each pub keys within range min version to latest_version
-	for i := int(minVersion); i <= int(keyVersion); i++ {
+	// symmetric and hmac key versions are their creation time, without public key
+	if HasPublicKey(keyType) {
+		// for each pub keys within range min version to latest_version
+		for i := int(minVersion); i <= int(keyVersion); i++ {
 
-		pub, err := k.GetPublicKeyFromTransitResponse(keyInfo, i)
-		if err != nil {
-			k.logger.Error("error parsing pub key from response", zap.Error(err))
-			return err
-		}
+			pub, err := k.GetPublicKeyFromTransitResponse(keyInfo, i)
+			if err != nil {
+				k.logger.Error("error parsing pub key from response", zap.Error(err))
+				return err
+			}
+
+			pubKey := NewTransitPublicKey(pub, i, k.Name)
 
-		pubKeys = append(pubKeys, NewTransitPublicKey(pub, i, k.Name))
+			// creation_time is only needed by OpenPGP keys, left zero if missing or invalid
+			pubKey.CreationTime, err = k.GetCreationTimeFromTransitResponse(keyInfo, i)
+			if err != nil {
+				k.logger.Debug("error parsing creation time from response", zap.Int("version", i), zap.Error(err))
+			}
 
+			pubKeys = append(pubKeys, pubKey)
+
+		}
 	}
 
 	k.Type = keyType
@@ -164,8 +186,19 @@ func (k *VaultTransitKey) SyncKeyInfo() error {
 
 }
 
+// HasPublicKey returns true if transit keys of keyType have a public key,
+// i.e. rsa, ecdsa and ed25519 keys
+func HasPublicKey(keyType string) bool {
+	return keyType == "ed25519" || strings.HasPrefix(keyType, "rsa-") || strings.HasPrefix(keyType, "ecdsa-")
+}
+
 // Sign byte payload, and returns "signature" output of transit sign api
 func (k *VaultTransitKey) Sign(inputBytes []byte, apiSigAlg string, apiHashAlg string, marshallingAlg string, prehashed bool) (string, error) {
+	return k.sign(inputBytes, apiSigAlg, apiHashAlg, marshallingAlg, prehashed, k.Version, k.SaltLength)
+}
+
+// sign byte payload with key_version and the salt_length of 'pss' signatures
+func (k *VaultTransitKey) sign(inputBytes []byte, apiSigAlg string, apiHashAlg string, marshallingAlg string, prehashed bool, version int, saltLength string) (string, error) {
 
 	args := map[string]interface{}{
 		// transit required input to base64 encoded
@@ -173,7 +206,11 @@ func (k *VaultTransitKey) Sign(inputBytes []byte, apiSigAlg string, apiHashAlg s
 		"signature_algorithm":  apiSigAlg,
 		"marshaling_algorithm": marshallingAlg,
 		"prehashed":            prehashed,
-		"key_version":          k.Version,
+		"key_version":          version,
+	}
+
+	if apiSigAlg == "pss" && saltLength != "" {
+		args["salt_length"] = saltLength
 	}
 
 	// sign with transit API
@@ -210,6 +247,10 @@ func (k *VaultTransitKey) Verify(inputBytes []byte, signature string, apiSigAlg
 		"prehashed":            prehashed,
 	}
 
+	if apiSigAlg == "pss" && k.SaltLength != "" {
+		args["salt_length"] = k.SaltLength
+	}
+
 	// sign with transit API
 	signingPath := fmt.Sprintf("%s/verify/%s/%s", k.MountPath, k.Name, apiHashAlg)
 	transitResp, err := k.client.Logical().WriteWithContext(k.ctx, signingPath, args)
@@ -234,6 +275,35 @@ func (k *VaultTransitKey) SetSigKeyVersion(v int) {
 	k.SigVersion = v
 }
 
+// SetSaltLength sets the salt_length of 'pss' signatures, one of 'auto', 'hash'
+// or a number of bytes (requires Vault 1.14+)
+func (k *VaultTransitKey) SetSaltLength(saltLength string) {
+	k.SaltLength = saltLength
+}
+
+// CryptoHashToVaultHash returns the transit hash_algorithm of hash
+func CryptoHashToVaultHash(hash crypto.Hash) (string, error) {
+
+	hashAlg, ok := cryptoHashToVaultHash[hash]
+	if !ok {
+		return "", fmt.Errorf("unsupported hash %s", hash.String())
+	}
+
+	return hashAlg, nil
+}
+
+// VaultHashToCryptoHash returns the crypto.Hash of the transit hash_algorithm
+func VaultHashToCryptoHash(hashAlg string) (crypto.Hash, error) {
+
+	for hash, name := range cryptoHashToVaultHash {
+		if name == hashAlg {
+			return hash, nil
+		}
+	}
+
+	return crypto.Hash(0), fmt.Errorf("unsupported hash algorithm '%s'", hashAlg)
+}
+
 // GetPublicKeyFromTransitResponse return parsed public key from the keyInfo transit read API response
 func (k *VaultTransitKey) GetPublicKeyFromTransitResponse(keyInfo *vault.Secret, version int) (crypto.PublicKey, error) {
 
@@ -258,6 +328,26 @@ func (k *VaultTransitKey) GetPublicKeyFromTransitResponse(keyInfo *vault.Secret,
 	if err != nil {
 		return nil, err
 	}
+
+	return ParseTransitPublicKey(k.Type, key)
+}
+
+// ParseTransitPublicKey parses the transit 'public_key' of a key of type keyType,
+// raw base64 for ed25519 keys and PEM for other key types
+func ParseTransitPublicKey(keyType, key string) (crypto.PublicKey, error) {
+
+	if keyType == "ed25519" {
+		raw, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("error base64 decoding ed25519 pub key: %w", err)
+		}
+		if len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid ed25519 pub key size %d", len(raw))
+		}
+
+		return ed25519.PublicKey(raw), nil
+	}
+
 	block, _ := pem.Decode([]byte(key))
 
 	if block == nil {
@@ -270,3 +360,31 @@ func (k *VaultTransitKey) GetPublicKeyFromTransitResponse(keyInfo *vault.Secret,
 
 	return pub, nil
 }
+
+// GetCreationTimeFromTransitResponse return parsed creation_time of the key version
+// from the keyInfo transit read API response
+func (k *VaultTransitKey) GetCreationTimeFromTransitResponse(keyInfo *vault.Secret, version int) (time.Time, error) {
+
+	// Build jq query
+	jqQuery := fmt.Sprintf(".keys.%d.creation_time", version)
+
+	op, err := jq.Parse(jqQuery)
+	if err != nil {
+		k.logger.Debug("jq query", zap.String("query", jqQuery))
+		return time.Time{}, err
+	}
+	data, err := json.Marshal(keyInfo.Data)
+	if err != nil {
+		return time.Time{}, err
+	}
+	value, err := op.Apply(data)
+	if err != nil {
+		return time.Time{}, err
+	}
+	creationTime, err := strconv.Unquote(string(value))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Parse(time.RFC3339Nano, creationTime)
+}
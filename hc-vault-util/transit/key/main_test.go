@@ -0,0 +1,141 @@
+package key
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/internal/transittest"
+)
+
+// transit read key 'public_key' of an ed25519 key (RFC 8032 test 1 public key)
+const vaultEd25519PublicKey = "11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo="
+
+func TestParseTransitPublicKey(t *testing.T) {
+
+	pub, err := ParseTransitPublicKey("ed25519", vaultEd25519PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edPub, ok := pub.(ed25519.PublicKey)
+	if !ok {
+		t.Fatalf("public key = %T, want ed25519.PublicKey", pub)
+	}
+	want, _ := hex.DecodeString("d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a")
+	if !bytes.Equal(edPub, want) {
+		t.Errorf("public key = %x, want %x", []byte(edPub), want)
+	}
+
+	for _, invalid := range []string{"not base64!", "AAAA"} {
+		_, err = ParseTransitPublicKey("ed25519", invalid)
+		if err == nil {
+			t.Errorf("ParseTransitPublicKey(%q) succeeded, want error", invalid)
+		}
+	}
+
+	// PEM for other key types
+	_, err = ParseTransitPublicKey("ecdsa-p256", vaultEd25519PublicKey)
+	if err == nil {
+		t.Error("ParseTransitPublicKey of base64 ecdsa-p256 key succeeded, want error")
+	}
+}
+
+func TestSyncKeyInfoEd25519(t *testing.T) {
+
+	fake := transittest.GenerateKey(t, "ed25519", 2)
+	client := transittest.NewClient(t, map[string]*transittest.Key{"ed": fake})
+	k := syncFakeKey(t, client, "ed")
+
+	if k.Type != "ed25519" || k.Version != 2 || len(k.PublicKeys) != 2 {
+		t.Fatalf("synced key type=%s version=%d public keys=%d", k.Type, k.Version, len(k.PublicKeys))
+	}
+
+	s, err := NewTransitSignerForVersion(k, 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pub, ok := s.Public().(ed25519.PublicKey)
+	if !ok {
+		t.Fatalf("Public() = %T, want ed25519.PublicKey", s.Public())
+	}
+	if !pub.Equal(fake.Versions[0].Public()) {
+		t.Error("Public() is not the public key of version 1")
+	}
+
+	// ed25519 signs the message, not a digest
+	msg := []byte("hello")
+	sig, err := s.Sign(rand.Reader, msg, crypto.Hash(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(pub, msg, sig) {
+		t.Error("signature does not verify with Public()")
+	}
+}
+
+func TestSyncKeyInfoECDSA(t *testing.T) {
+
+	fake := transittest.GenerateKey(t, "ecdsa-p256", 1)
+	client := transittest.NewClient(t, map[string]*transittest.Key{"ec": fake})
+	k := syncFakeKey(t, client, "ec")
+
+	pub, ok := k.PublicKeys[0].PublicKey.(*ecdsa.PublicKey)
+	if !ok || !pub.Equal(fake.Versions[0].Public()) {
+		t.Errorf("public key = %T, want the ecdsa public key of version 1", k.PublicKeys[0].PublicKey)
+	}
+}
+
+func TestSyncKeyInfoNoCreationTime(t *testing.T) {
+
+	fake := transittest.GenerateKey(t, "ecdsa-p256", 2)
+	fake.NoCreationTime = true
+	client := transittest.NewClient(t, map[string]*transittest.Key{"ec": fake})
+	k := syncFakeKey(t, client, "ec")
+
+	for _, pub := range k.PublicKeys {
+		if !pub.CreationTime.IsZero() {
+			t.Errorf("version %d creation time = %s, want zero", pub.Version, pub.CreationTime)
+		}
+	}
+
+	fake.NoCreationTime = false
+	k = syncFakeKey(t, client, "ec")
+
+	want := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	if !k.PublicKeys[1].CreationTime.Equal(want) {
+		t.Errorf("version 2 creation time = %s, want %s", k.PublicKeys[1].CreationTime, want)
+	}
+}
+
+func TestSyncKeyInfoSymmetric(t *testing.T) {
+
+	keys := map[string]*transittest.Key{}
+	for _, keyType := range []string{"aes128-gcm96", "aes256-gcm96", "chacha20-poly1305", "hmac"} {
+		keys[keyType] = transittest.NewSymmetricKey(keyType, 2)
+	}
+	client := transittest.NewClient(t, keys)
+
+	for keyType := range keys {
+		k := syncFakeKey(t, client, keyType)
+
+		if k.Type != keyType || k.Version != 2 || k.MinVersion != 1 {
+			t.Errorf("synced key type=%s version=%d min version=%d", k.Type, k.Version, k.MinVersion)
+		}
+		if len(k.PublicKeys) != 0 {
+			t.Errorf("%s has %d public keys, want none", keyType, len(k.PublicKeys))
+		}
+
+		_, err := NewTransitSignerForVersion(k, 0, "")
+		if !errors.Is(err, ErrNoPublicKey) {
+			t.Errorf("%s signer error = %v, want ErrNoPublicKey", keyType, err)
+		}
+	}
+}
@@ -2,52 +2,113 @@ package key
 
 import (
 	"crypto"
+	"crypto/rsa"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 
 	"go.uber.org/zap"
 )
 
+// ErrNoPublicKey is returned for a transit key without public key, e.g. not
+// synced with SyncKeyInfo or not an asymmetric key
+var ErrNoPublicKey = errors.New("no public key")
+
+// KeyVersionError is returned for a version outside of min_decryption_version
+// and latest_version of the transit key
+type KeyVersionError struct {
+	Name          string
+	Version       int
+	MinVersion    int
+	LatestVersion int
+}
+
+func (e *KeyVersionError) Error() string {
+	return fmt.Sprintf("invalid version %d of transit key '%s', must be within %d and %d", e.Version, e.Name, e.MinVersion, e.LatestVersion)
+}
+
 // TransitSigner  implement crypto.signer interface
 // https://pkg.go.dev/crypto#Signer
 type TransitSigner struct {
 	Key *VaultTransitKey
 	// signature_algorithm one of "pss" or "pkcs1v15"
 	SigAlg string
+	// key_version of transit sign
+	Version int
+
+	// public key of the signing version
+	pub crypto.PublicKey
 }
 
 // NewTransitSigner with transit key and signature_algorithm one of "pss" or "pkcs1v15"
+//
+// Deprecated: use NewTransitSignerForVersion, which validates the key version.
 func NewTransitSigner(k *VaultTransitKey, SigAlg string) *TransitSigner {
 
 	return &TransitSigner{
-		Key:    k,
-		SigAlg: SigAlg,
+		Key:     k,
+		SigAlg:  SigAlg,
+		Version: k.Version,
 	}
 }
 
-// Public returns the public key corresponding to the opaque,
-// private key.
-func (s *TransitSigner) Public() crypto.PublicKey {
+// NewTransitSignerForVersion with version (or latest if 0) of the synced transit
+// key and signature_algorithm one of "pss" or "pkcs1v15". Returns ErrNoPublicKey
+// or a *KeyVersionError if the version has no public key.
+func NewTransitSignerForVersion(k *VaultTransitKey, version int, sigAlg string) (*TransitSigner, error) {
 
-	version := s.Key.Version
+	if len(k.PublicKeys) == 0 {
+		return nil, fmt.Errorf("%w for %s/keys/%s", ErrNoPublicKey, k.MountPath, k.Name)
+	}
 
-	var pub crypto.PublicKey
-	for _, k := range s.Key.PublicKeys {
+	if version == 0 {
+		version = k.Version
+	}
 
-		if k.Version == version {
-			pub = k.PublicKey
-			break
+	for _, p := range k.PublicKeys {
+		if p.Version != version {
+			continue
 		}
+
+		if p.PublicKey == nil {
+			return nil, fmt.Errorf("%w for version %d of %s/keys/%s", ErrNoPublicKey, version, k.MountPath, k.Name)
+		}
+
+		return &TransitSigner{
+			Key:     k,
+			SigAlg:  sigAlg,
+			Version: version,
+			pub:     p.PublicKey,
+		}, nil
+	}
+
+	return nil, &KeyVersionError{
+		Name:          k.Name,
+		Version:       version,
+		MinVersion:    k.MinVersion,
+		LatestVersion: k.Version,
 	}
+}
+
+// Public returns the public key corresponding to the opaque,
+// private key, or nil if the signing version has no public key.
+func (s *TransitSigner) Public() crypto.PublicKey {
 
-	if pub == nil {
-		// if cannot find version default to last public key
-		pub = s.Key.PublicKeys[len(s.Key.PublicKeys)-1]
+	if s.pub != nil {
+		return s.pub
 	}
 
-	return pub
+	// signer from NewTransitSigner
+	for _, k := range s.Key.PublicKeys {
+		if k.Version == s.Version {
+			return k.PublicKey
+		}
+	}
+
+	return nil
 }
 
 // Sign signs digest with the private key, possibly using entropy from
@@ -67,15 +128,29 @@ func (s *TransitSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOp
 
 	// convert hash
 	hash := opts.HashFunc()
-	hashAlg, ok := cryptoHashToVaultHash[hash]
-	if !ok {
-		return nil, fmt.Errorf("unsupported hash %s", hash.String())
 
+	// ed25519 signs the whole message (no hash)
+	hashAlg := "sha2-256"
+	prehashed := false
+	if hash != crypto.Hash(0) {
+		hashAlg, err = CryptoHashToVaultHash(hash)
+		if err != nil {
+			return nil, err
+		}
+		prehashed = true
+	}
+
+	// RSA PSS signature requested by opts (e.g. x509.SHA256WithRSAPSS)
+	sigAlg := s.SigAlg
+	saltLength := s.Key.SaltLength
+	if pssOpts, ok := opts.(*rsa.PSSOptions); ok {
+		sigAlg = "pss"
+		saltLength = PSSSaltLengthToVault(pssOpts.SaltLength)
 	}
 
-	s.Key.logger.Debug("Hash alg", zap.String("opts.HashFunc()", hash.String()), zap.String("ohashAlg", hashAlg))
+	s.Key.logger.Debug("Hash alg", zap.String("opts.HashFunc()", hash.String()), zap.String("ohashAlg", hashAlg), zap.String("sigAlg", sigAlg))
 	// sign with vault transit key
-	sigVault, err := s.Key.Sign(digest, s.SigAlg, hashAlg, "asn1", true)
+	sigVault, err := s.Key.sign(digest, sigAlg, hashAlg, "asn1", prehashed, s.Version, saltLength)
 	if err != nil {
 		return nil, err
 	}
@@ -98,3 +173,17 @@ func (s *TransitSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOp
 	return sigBytes, nil
 
 }
+
+// PSSSaltLengthToVault returns the transit salt_length of the rsa.PSSOptions
+// SaltLength
+func PSSSaltLengthToVault(saltLength int) string {
+
+	switch saltLength {
+	case rsa.PSSSaltLengthAuto:
+		return "auto"
+	case rsa.PSSSaltLengthEqualsHash:
+		return "hash"
+	default:
+		return strconv.Itoa(saltLength)
+	}
+}
@@ -0,0 +1,127 @@
+package key
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	vault "github.com/hashicorp/vault/api"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/internal/transittest"
+	"go.uber.org/zap"
+)
+
+// syncFakeKey returns the synced transit key name of the fake transit backend
+func syncFakeKey(t *testing.T, client *vault.Client, name string) *VaultTransitKey {
+	t.Helper()
+
+	k, err := NewVaultTransitKey(context.Background(), zap.NewNop(), client, transittest.Mount, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = k.SyncKeyInfo()
+	if err != nil {
+		t.Fatalf("SyncKeyInfo: %v", err)
+	}
+
+	return k
+}
+
+func TestNewTransitSignerForVersion(t *testing.T) {
+
+	fake := transittest.GenerateKey(t, "ecdsa-p256", 3)
+	client := transittest.NewClient(t, map[string]*transittest.Key{"ec": fake})
+
+	tests := []struct {
+		name    string
+		version int
+		want    int
+	}{
+		{name: "latest", version: 0, want: 3},
+		{name: "explicit", version: 2, want: 2},
+		{name: "first", version: 1, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			k := syncFakeKey(t, client, "ec")
+
+			s, err := NewTransitSignerForVersion(k, tt.version, "pss")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if s.Version != tt.want {
+				t.Errorf("signer version = %d, want %d", s.Version, tt.want)
+			}
+			// the synced key keeps latest_version
+			if k.Version != 3 {
+				t.Errorf("key version = %d, want latest 3", k.Version)
+			}
+
+			pub, ok := s.Public().(*ecdsa.PublicKey)
+			if !ok {
+				t.Fatalf("Public() = %T, want *ecdsa.PublicKey", s.Public())
+			}
+			if !pub.Equal(fake.Versions[tt.want-1].Public()) {
+				t.Errorf("Public() is not the public key of version %d", tt.want)
+			}
+
+			digest := sha256.Sum256([]byte("hello"))
+			sig, err := s.Sign(rand.Reader, digest[:], crypto.SHA256)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fake.LastSignVersion != tt.want {
+				t.Errorf("signed with key_version %d, want %d", fake.LastSignVersion, tt.want)
+			}
+			if !ecdsa.VerifyASN1(pub, digest[:], sig) {
+				t.Error("signature does not verify with Public()")
+			}
+		})
+	}
+}
+
+func TestNewTransitSignerForVersionMissingVersion(t *testing.T) {
+
+	fake := transittest.GenerateKey(t, "ecdsa-p256", 3)
+	fake.MinVersion = 2
+	client := transittest.NewClient(t, map[string]*transittest.Key{"ec": fake})
+	k := syncFakeKey(t, client, "ec")
+
+	for _, version := range []int{1, 4, -1} {
+
+		_, err := NewTransitSignerForVersion(k, version, "pss")
+
+		var verr *KeyVersionError
+		if !errors.As(err, &verr) {
+			t.Fatalf("version %d: error = %v, want *KeyVersionError", version, err)
+		}
+		want := KeyVersionError{Name: "ec", Version: version, MinVersion: 2, LatestVersion: 3}
+		if *verr != want {
+			t.Errorf("version %d: error = %+v, want %+v", version, *verr, want)
+		}
+	}
+}
+
+func TestNewTransitSignerForVersionNoPublicKey(t *testing.T) {
+
+	// e.g. a symmetric key
+	k := &VaultTransitKey{MountPath: "transit", Name: "aes", Type: "aes256-gcm96", Version: 1}
+
+	_, err := NewTransitSignerForVersion(k, 0, "pss")
+	if !errors.Is(err, ErrNoPublicKey) {
+		t.Fatalf("error = %v, want ErrNoPublicKey", err)
+	}
+
+	k.PublicKeys = []*TransitPublicKey{NewTransitPublicKey(nil, 1, "aes")}
+	_, err = NewTransitSignerForVersion(k, 1, "pss")
+	if !errors.Is(err, ErrNoPublicKey) {
+		t.Fatalf("error = %v, want ErrNoPublicKey", err)
+	}
+}
@@ -0,0 +1,118 @@
+package transit
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"fmt"
+	"sort"
+)
+
+// keyAlgorithm maps a parsed private key to its transit key type
+type keyAlgorithm struct {
+	// transit key types supported for this algorithm
+	transitTypes []string
+	// keyType returns the transit key type of privKey,
+	// and false if privKey is not of this algorithm
+	keyType func(privKey crypto.PrivateKey) (string, bool)
+}
+
+// supportedKeyAlgorithms lists the private key algorithms that can be imported.
+//
+// To support a new transit key type (e.g. ML-DSA), add an entry matching the
+// private key type returned by the PEM parser.
+var supportedKeyAlgorithms = []keyAlgorithm{
+	{
+		transitTypes: []string{"rsa-2048", "rsa-3072", "rsa-4096"},
+		keyType: func(privKey crypto.PrivateKey) (string, bool) {
+			priv, ok := privKey.(*rsa.PrivateKey)
+			if !ok {
+				return "", false
+			}
+			// *8 to convert bytes to bits
+			return fmt.Sprintf("rsa-%d", priv.Size()*8), true
+		},
+	},
+	{
+		transitTypes: []string{"ecdsa-p256", "ecdsa-p384", "ecdsa-p521"},
+		keyType: func(privKey crypto.PrivateKey) (string, bool) {
+			priv, ok := privKey.(*ecdsa.PrivateKey)
+			if !ok {
+				return "", false
+			}
+			return fmt.Sprintf("ecdsa-p%d", priv.Params().BitSize), true
+		},
+	},
+	{
+		transitTypes: []string{"ed25519"},
+		keyType: func(privKey crypto.PrivateKey) (string, bool) {
+			// x509.ParsePKCS8PrivateKey returns ed25519 keys by value
+			switch privKey.(type) {
+			case ed25519.PrivateKey, *ed25519.PrivateKey:
+				return "ed25519", true
+			}
+			return "", false
+		},
+	},
+}
+
+// transitKeyType returns the transit key type of privKey
+func transitKeyType(privKey crypto.PrivateKey) (string, error) {
+
+	for _, alg := range supportedKeyAlgorithms {
+
+		keyType, ok := alg.keyType(privKey)
+		if !ok {
+			continue
+		}
+
+		for _, t := range alg.transitTypes {
+			if t == keyType {
+				return keyType, nil
+			}
+		}
+
+		return "", fmt.Errorf("unsupported key type '%s', must be one of %v", keyType, alg.transitTypes)
+	}
+
+	return "", fmt.Errorf("unsupported key type %T, must be one of %v", privKey, supportedTransitKeyTypes())
+}
+
+// supportedTransitKeyTypes returns all transit key types that can be imported from a private key
+func supportedTransitKeyTypes() []string {
+
+	types := []string{}
+	for _, alg := range supportedKeyAlgorithms {
+		types = append(types, alg.transitTypes...)
+	}
+
+	return types
+}
+
+// importKeyTypes returns all transit key types that can be imported: the
+// private key types, symmetric key types and hmac
+func importKeyTypes() []string {
+
+	symmetricTypes := []string{}
+	for keyType := range symmetricKeySizes {
+		symmetricTypes = append(symmetricTypes, keyType)
+	}
+	sort.Strings(symmetricTypes)
+
+	types := append(supportedTransitKeyTypes(), symmetricTypes...)
+	return append(types, "hmac")
+}
+
+// validateImportKeyType returns an error if keyType cannot be imported into transit
+func validateImportKeyType(keyType string) error {
+
+	types := importKeyTypes()
+	for _, t := range types {
+		if t == keyType {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unsupported key type '%s', must be one of %v", keyType, types)
+}
@@ -0,0 +1,122 @@
+package transit
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/internal/transittest"
+)
+
+func TestTransitKeyType(t *testing.T) {
+
+	newRSA := func(bits int) crypto.PrivateKey {
+		priv, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return priv
+	}
+	newECDSA := func(curve elliptic.Curve) crypto.PrivateKey {
+		priv, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return priv
+	}
+	_, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		privKey crypto.PrivateKey
+		want    string
+		wantErr bool
+	}{
+		{name: "rsa-2048", privKey: newRSA(2048), want: "rsa-2048"},
+		{name: "rsa-3072", privKey: newRSA(3072), want: "rsa-3072"},
+		{name: "rsa-4096", privKey: newRSA(4096), want: "rsa-4096"},
+		{name: "ecdsa-p256", privKey: newECDSA(elliptic.P256()), want: "ecdsa-p256"},
+		{name: "ecdsa-p384", privKey: newECDSA(elliptic.P384()), want: "ecdsa-p384"},
+		{name: "ecdsa-p521", privKey: newECDSA(elliptic.P521()), want: "ecdsa-p521"},
+		{name: "ed25519", privKey: edPriv, want: "ed25519"},
+		{name: "ed25519 pointer", privKey: &edPriv, want: "ed25519"},
+
+		{name: "rsa-1024", privKey: newRSA(1024), wantErr: true},
+		{name: "ecdsa-p224", privKey: newECDSA(elliptic.P224()), wantErr: true},
+		{name: "not a private key", privKey: "key", wantErr: true},
+		{name: "nil", privKey: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			got, err := transitKeyType(tt.privKey)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("transitKeyType = %s, want error", got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("transitKeyType = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSupportedTransitKeyTypes(t *testing.T) {
+
+	want := []string{"rsa-2048", "rsa-3072", "rsa-4096", "ecdsa-p256", "ecdsa-p384", "ecdsa-p521", "ed25519"}
+	if got := supportedTransitKeyTypes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("supportedTransitKeyTypes = %v, want %v", got, want)
+	}
+}
+
+func TestValidateImportKeyType(t *testing.T) {
+
+	valid := append(supportedTransitKeyTypes(), "aes128-gcm96", "aes256-gcm96", "chacha20-poly1305", "hmac")
+	for _, keyType := range valid {
+		err := validateImportKeyType(keyType)
+		if err != nil {
+			t.Errorf("validateImportKeyType(%s): %v", keyType, err)
+		}
+	}
+
+	for _, keyType := range []string{"", "rsa-1024", "ecdsa-p224", "aes256", "managed_key", "RSA-2048"} {
+		err := validateImportKeyType(keyType)
+		if err == nil {
+			t.Errorf("validateImportKeyType(%q) succeeded, want error", keyType)
+		}
+	}
+}
+
+func TestImportCiphertextInvalidKeyType(t *testing.T) {
+
+	existing := transittest.NewSymmetricKey("aes256-gcm96", 1)
+	tc := newTestTransitClient(t, "wrapped", existing)
+	tc.SetImportOptions(ImportOptions{NewVersion: true})
+
+	ciphertextFile := writeTestFile(t, "key.wrapped", base64.StdEncoding.EncodeToString(make([]byte, 552)))
+
+	err := tc.ImportCiphertext(ciphertextFile, "aes-256")
+	if err == nil || !strings.Contains(err.Error(), "unsupported key type 'aes-256'") {
+		t.Fatalf("ImportCiphertext error = %v, want unsupported key type", err)
+	}
+	if len(existing.Versions) != 1 || len(existing.Imported) != 0 {
+		t.Error("ciphertext of invalid key type imported")
+	}
+}
@@ -0,0 +1,21 @@
+package transit
+
+import (
+	"fmt"
+)
+
+// writeKV2Secret writes data as a new version of the kv2 secret at mount/secretPath
+func (t *TransitClient) writeKV2Secret(mount, secretPath string, data map[string]interface{}) error {
+
+	secret, err := t.client.KVv2(mount).Put(t.ctx, secretPath, data)
+	if err != nil {
+		return err
+	}
+
+	if secret == nil || secret.VersionMetadata == nil {
+		return fmt.Errorf("no response writing kv2 secret %s/%s", mount, secretPath)
+	}
+
+	t.logger.Debug("kv2 secret written", "mount", mount, "path", secretPath, "version", secret.VersionMetadata.Version)
+	return nil
+}
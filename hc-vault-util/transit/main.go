@@ -2,9 +2,12 @@ package transit
 
 import (
 	"context"
+	"crypto/rsa"
 
 	"github.com/hashicorp/go-hclog"
 	vault "github.com/hashicorp/vault/api"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/logger"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/key"
 )
 
 type TransitClient struct {
@@ -17,6 +20,13 @@ type TransitClient struct {
 	// key properties
 	transitMount string
 	keyName      string
+
+	// import options
+	importOpts ImportOptions
+	// wrapping key, read from transit if not set
+	wrappingKey *rsa.PublicKey
+	// wrapping keys read from transit by mount
+	wrappingKeys map[string]*rsa.PublicKey
 }
 
 func NewTransitClient(l hclog.Logger) (*TransitClient, error) {
@@ -29,15 +39,57 @@ func NewTransitClient(l hclog.Logger) (*TransitClient, error) {
 	}
 
 	return &TransitClient{
-		logger: l,
-		client: client,
-		ctx:    ctx,
+		logger:       l,
+		client:       client,
+		ctx:          ctx,
+		wrappingKeys: map[string]*rsa.PublicKey{},
 	}, nil
 
 }
 
+// NewOfflineTransitClient returns a client without vault connection,
+// only usable for offline operations (e.g. wrapping keys with SetWrappingKey)
+func NewOfflineTransitClient(l hclog.Logger) *TransitClient {
+
+	return &TransitClient{
+		logger: l,
+		ctx:    context.Background(),
+	}
+}
+
 func (t *TransitClient) SetKeyProperties(transitMount, keyName string) {
 
 	t.keyName = keyName
 	t.transitMount = transitMount
 }
+
+// SetImportOptions sets the options used by the next key imports
+func (t *TransitClient) SetImportOptions(opts ImportOptions) {
+
+	t.importOpts = opts
+}
+
+// syncTransitKey returns the transit key for the current key properties
+// with its latest info from transit
+func (t *TransitClient) syncTransitKey() (*key.VaultTransitKey, error) {
+
+	// get zap logger from hclog properties
+	hasDebug := t.logger.IsDebug()
+	hasNoColor := true
+	zapLog := logger.GetZapLogger(hasDebug, hasNoColor)
+
+	// create a new transit key
+	k, err := key.NewVaultTransitKey(t.ctx, zapLog, t.client, t.transitMount, t.keyName)
+	if err != nil {
+		return nil, err
+	}
+
+	// get latest key info
+	// version public keys
+	err = k.SyncKeyInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
@@ -0,0 +1,34 @@
+package transit
+
+import (
+	"context"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/internal/transittest"
+)
+
+// newTestTransitClient returns a transit client for the key name of a new
+// fake transit backend with the single key k
+func newTestTransitClient(t *testing.T, name string, k *transittest.Key) *TransitClient {
+	t.Helper()
+
+	srv := transittest.NewServer(t, map[string]*transittest.Key{name: k})
+
+	return newTestServerClient(srv, name)
+}
+
+// newTestServerClient returns a transit client for the key name of the fake transit backend srv
+func newTestServerClient(srv *transittest.Server, name string) *TransitClient {
+
+	tc := &TransitClient{
+		logger:       hclog.NewNullLogger(),
+		client:       srv.Client(),
+		ctx:          context.Background(),
+		wrappingKeys: map[string]*rsa.PublicKey{},
+	}
+	tc.SetKeyProperties(transittest.Mount, name)
+
+	return tc
+}
@@ -0,0 +1,105 @@
+package transit
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KeyManifest lists keys to import in bulk
+type KeyManifest struct {
+	Keys []ManifestEntry `yaml:"keys"`
+}
+
+// ManifestEntry is a key to import with its import options
+type ManifestEntry struct {
+	// transit key name
+	Name string `yaml:"name"`
+	// transit mount, or default mount if empty
+	Mount string `yaml:"mount"`
+
+	ImportSource  `yaml:",inline"`
+	ImportOptions `yaml:",inline"`
+}
+
+// ImportResult is the outcome of the import of a manifest entry
+type ImportResult struct {
+	Mount string
+	Name  string
+	// import error, nil on success
+	Err error
+}
+
+// LoadKeyManifest reads the YAML key manifest from file. Relative file paths
+// of the entries are relative to the directory of the manifest.
+func LoadKeyManifest(file string) (*KeyManifest, error) {
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &KeyManifest{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	// reject typos in option names
+	dec.KnownFields(true)
+	err = dec.Decode(m)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing manifest %s: %w", file, err)
+	}
+
+	dir := filepath.Dir(file)
+	for i := range m.Keys {
+		e := &m.Keys[i]
+		if e.Name == "" {
+			return nil, fmt.Errorf("missing name for manifest entry %d", i)
+		}
+
+		for _, path := range []*string{&e.KeyFile, &e.PKCS12, &e.SymmetricKey, &e.CiphertextFile, &e.PassphraseFile} {
+			if *path != "" && !filepath.IsAbs(*path) {
+				*path = filepath.Join(dir, *path)
+			}
+		}
+
+		err = e.ImportSource.ValidateOptions(e.ImportOptions)
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest entry '%s': %w", e.Name, err)
+		}
+	}
+
+	return m, nil
+}
+
+// ImportManifest imports every key of the manifest, keeps going when an import
+// fails, and returns the result for each key
+func (t *TransitClient) ImportManifest(m *KeyManifest, defaultMount string) []ImportResult {
+
+	results := make([]ImportResult, 0, len(m.Keys))
+	for _, e := range m.Keys {
+
+		mount := e.Mount
+		if mount == "" {
+			mount = defaultMount
+		}
+
+		t.SetKeyProperties(mount, e.Name)
+		t.SetImportOptions(e.ImportOptions)
+
+		t.logger.Info("Importing key", "path", fmt.Sprintf("%s/keys/%s", mount, e.Name))
+		err := t.Import(e.ImportSource)
+		if err != nil {
+			t.logger.Error("Error importing key", "path", fmt.Sprintf("%s/keys/%s", mount, e.Name), "error", err)
+		}
+
+		results = append(results, ImportResult{
+			Mount: mount,
+			Name:  e.Name,
+			Err:   err,
+		})
+	}
+
+	return results
+}
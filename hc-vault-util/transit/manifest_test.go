@@ -0,0 +1,56 @@
+package transit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadKeyManifestRelativePaths(t *testing.T) {
+
+	dir := t.TempDir()
+	abs := filepath.Join(t.TempDir(), "abs.pem")
+	manifest := filepath.Join(dir, "keys.yaml")
+
+	err := os.WriteFile(manifest, []byte(`
+keys:
+  - name: rsa-key
+    key_file: ./keys/rsa.pem
+    passphrase_file: rsa.pass
+  - name: ec-key
+    pkcs12: ../ec.p12
+  - name: aes-key
+    symmetric_key: aes.hex
+    key_type: aes256-gcm96
+  - name: wrapped-key
+    ciphertext_file: wrapped/key.b64
+    key_type: rsa-2048
+  - name: abs-key
+    key_file: `+abs+`
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := LoadKeyManifest(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ImportSource{
+		{KeyFile: filepath.Join(dir, "keys/rsa.pem"), PassphraseFile: filepath.Join(dir, "rsa.pass")},
+		{PKCS12: filepath.Join(filepath.Dir(dir), "ec.p12")},
+		{SymmetricKey: filepath.Join(dir, "aes.hex"), KeyType: "aes256-gcm96"},
+		{CiphertextFile: filepath.Join(dir, "wrapped/key.b64"), KeyType: "rsa-2048"},
+		{KeyFile: abs},
+	}
+
+	if len(m.Keys) != len(want) {
+		t.Fatalf("manifest has %d keys, want %d", len(m.Keys), len(want))
+	}
+	for i, e := range m.Keys {
+		if e.ImportSource != want[i] {
+			t.Errorf("%s source = %+v, want %+v", e.Name, e.ImportSource, want[i])
+		}
+	}
+}
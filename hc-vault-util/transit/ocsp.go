@@ -0,0 +1,134 @@
+package transit
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// OCSPOptions are the options of an OCSP response signed with a transit key
+type OCSPOptions struct {
+	// validity period from now
+	NextUpdate time.Duration
+}
+
+// GenOCSPResponse generates the OCSP response for the serial number (or the
+// DER OCSP request requestFile if serial is nil) from the revoked certificates of
+// revokedFile, signed by the CA certificate caCertFile whose private key is version
+// keyVersion (or latest if 0) of the transit key, and writes it DER encoded to
+// outFile (or stdout)
+func (t *TransitClient) GenOCSPResponse(caCertFile string, keyVersion int, revokedFile, requestFile string, serial *big.Int, opts OCSPOptions, outFile string) error {
+
+	revoked, err := LoadRevocationList(revokedFile)
+	if err != nil {
+		t.logger.Error("Error loading revocation list", "error", err)
+		return err
+	}
+
+	caCert, signer, err := t.loadCASigner(caCertFile, keyVersion)
+	if err != nil {
+		return err
+	}
+
+	issuerHash := crypto.SHA1
+	if serial == nil {
+		data, err := os.ReadFile(requestFile)
+		if err != nil {
+			t.logger.Error("Error reading OCSP request", "error", err)
+			return err
+		}
+
+		req, err := ocsp.ParseRequest(data)
+		if err != nil {
+			t.logger.Error("Error parsing OCSP request", "error", err)
+			return err
+		}
+
+		err = checkOCSPRequestIssuer(req, caCert)
+		if err != nil {
+			return err
+		}
+
+		serial = req.SerialNumber
+		issuerHash = req.HashAlgorithm
+	}
+
+	der, status, err := CreateOCSPResponse(caCert, signer, serial, issuerHash, revoked, opts)
+	if err != nil {
+		return err
+	}
+
+	t.logger.Info("DER encoded OCSP response", "serial", serial.String(), "status", status)
+
+	return writeOutput(outFile, der, 0644)
+}
+
+// CreateOCSPResponse returns the DER encoded OCSP response for serial and its
+// status, issued by caCert and signed by signer: 'revoked' if revoked, 'good'
+// if in the issued certificates of the list, or 'unknown' otherwise
+func CreateOCSPResponse(caCert *x509.Certificate, signer crypto.Signer, serial *big.Int, issuerHash crypto.Hash, revoked *RevocationList, opts OCSPOptions) ([]byte, string, error) {
+
+	now := time.Now()
+	tpl := ocsp.Response{
+		Status:       ocsp.Unknown,
+		SerialNumber: serial,
+		IssuerHash:   issuerHash,
+		ThisUpdate:   now,
+		NextUpdate:   now.Add(opts.NextUpdate),
+	}
+
+	status := "unknown"
+	if r := revoked.Find(serial); r != nil {
+		status = "revoked"
+		tpl.Status = ocsp.Revoked
+		tpl.RevokedAt = r.RevokedAt
+		tpl.RevocationReason = r.reasonCode
+	} else if revoked.IsIssued(serial) {
+		status = "good"
+		tpl.Status = ocsp.Good
+	}
+
+	// the CA is the OCSP responder
+	der, err := ocsp.CreateResponse(caCert, caCert, tpl, signer)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return der, status, nil
+}
+
+// checkOCSPRequestIssuer returns an error if the OCSP request is not for caCert
+func checkOCSPRequestIssuer(req *ocsp.Request, caCert *x509.Certificate) error {
+
+	if !req.HashAlgorithm.Available() {
+		return fmt.Errorf("unsupported OCSP request hash algorithm")
+	}
+
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	_, err := asn1.Unmarshal(caCert.RawSubjectPublicKeyInfo, &spki)
+	if err != nil {
+		return err
+	}
+
+	nameHash := req.HashAlgorithm.New()
+	nameHash.Write(caCert.RawSubject)
+	keyHash := req.HashAlgorithm.New()
+	keyHash.Write(spki.PublicKey.RightAlign())
+
+	if !bytes.Equal(nameHash.Sum(nil), req.IssuerNameHash) || !bytes.Equal(keyHash.Sum(nil), req.IssuerKeyHash) {
+		return fmt.Errorf("OCSP request is not for CA '%s'", caCert.Subject.String())
+	}
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package transit
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/internal/transittest"
+	"golang.org/x/crypto/ocsp"
+)
+
+func TestGenOCSPResponse(t *testing.T) {
+
+	revokedFile := writeTestFile(t, "revoked.yaml", testRevocationList)
+
+	tests := []struct {
+		name   string
+		serial *big.Int
+		want   int
+	}{
+		{name: "revoked", serial: big.NewInt(0x1f3a9c), want: ocsp.Revoked},
+		{name: "issued", serial: big.NewInt(0x2b4d1e), want: ocsp.Good},
+		{name: "unknown", serial: big.NewInt(0x3c5e2f), want: ocsp.Unknown},
+	}
+
+	// x/crypto/ocsp does not support ed25519 responders
+	for _, keyType := range []string{"rsa-2048", "ecdsa-p256"} {
+
+		k := transittest.GenerateKey(t, keyType, 1)
+		caFile, caCert := newTestCA(t, k, 1)
+		tc := newTestTransitClient(t, "ca", k)
+
+		for _, tt := range tests {
+			t.Run(keyType+" "+tt.name, func(t *testing.T) {
+
+				signCount := k.SignCount
+
+				opts := OCSPOptions{NextUpdate: 24 * time.Hour}
+				out := filepath.Join(t.TempDir(), "ocsp.der")
+				err := tc.GenOCSPResponse(caFile, 0, revokedFile, "", tt.serial, opts, out)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if k.SignCount != signCount+1 {
+					t.Errorf("transit signed %d times, want once", k.SignCount-signCount)
+				}
+
+				der, err := os.ReadFile(out)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// checks the signature
+				resp, err := ocsp.ParseResponse(der, caCert)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if resp.Status != tt.want {
+					t.Errorf("response status = %d, want %d", resp.Status, tt.want)
+				}
+				if resp.SerialNumber.Cmp(tt.serial) != 0 {
+					t.Errorf("response serial = %s, want %s", resp.SerialNumber, tt.serial)
+				}
+				if validity := resp.NextUpdate.Sub(resp.ThisUpdate); validity != opts.NextUpdate {
+					t.Errorf("response validity = %s, want %s", validity, opts.NextUpdate)
+				}
+				if tt.want == ocsp.Revoked && (resp.RevocationReason != ocsp.KeyCompromise || !resp.RevokedAt.Equal(time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC))) {
+					t.Errorf("revoked at %s reason %d, want 2024-01-31T12:00:00Z reason %d", resp.RevokedAt, resp.RevocationReason, ocsp.KeyCompromise)
+				}
+			})
+		}
+	}
+}
+
+func TestGenOCSPResponseFromRequest(t *testing.T) {
+
+	revokedFile := writeTestFile(t, "revoked.yaml", testRevocationList)
+
+	k := transittest.GenerateKey(t, "ecdsa-p256", 1)
+	caFile, caCert := newTestCA(t, k, 1)
+	tc := newTestTransitClient(t, "ca", k)
+
+	// revoked certificate issued by the CA
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(0x1f3a9c),
+		Subject:      pkix.Name{CommonName: "revoked.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, tpl, caCert, transittest.GenerateSigner(t, "ecdsa-p256").Public(), k.Versions[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reqDER, err := ocsp.CreateRequest(cert, caCert, &ocsp.RequestOptions{Hash: crypto.SHA256})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reqFile := writeTestFile(t, "request.der", string(reqDER))
+
+	out := filepath.Join(t.TempDir(), "ocsp.der")
+	err = tc.GenOCSPResponse(caFile, 0, revokedFile, reqFile, nil, OCSPOptions{NextUpdate: time.Hour}, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := ocsp.ParseResponseForCert(der, cert, caCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != ocsp.Revoked || resp.IssuerHash != crypto.SHA256 {
+		t.Errorf("response status %d issuer hash %s, want revoked with SHA-256", resp.Status, resp.IssuerHash)
+	}
+
+	// request for another CA
+	other := transittest.GenerateKey(t, "ecdsa-p256", 1)
+	otherFile, _ := newTestCA(t, other, 1)
+	tc = newTestTransitClient(t, "ca", other)
+	err = tc.GenOCSPResponse(otherFile, 0, revokedFile, reqFile, nil, OCSPOptions{NextUpdate: time.Hour}, filepath.Join(t.TempDir(), "other.der"))
+	if err == nil {
+		t.Error("GenOCSPResponse of a request for another CA succeeded, want error")
+	}
+}
+
+func TestLoadRevocationListIssuedAndRevoked(t *testing.T) {
+
+	_, err := LoadRevocationList(writeTestFile(t, "revoked.yaml", `
+revoked:
+  - serial: "0x1f3a9c"
+    revoked_at: 2024-01-31T12:00:00Z
+issued:
+  - "2046620"
+`))
+	if err == nil {
+		t.Error("LoadRevocationList of a serial both issued and revoked succeeded, want error")
+	}
+}
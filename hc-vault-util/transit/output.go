@@ -0,0 +1,71 @@
+package transit
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// writeOutput writes data to outFile with permission perm, or to stdout
+// if outFile is empty or '-'
+func writeOutput(outFile string, data []byte, perm os.FileMode) error {
+
+	if outFile == "" || outFile == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	return os.WriteFile(outFile, data, perm)
+}
+
+// createOutput writes data to the new file outFile with permission perm, or to
+// stdout if outFile is empty or '-'. An existing outFile is an error, unless
+// force is set to replace it. Symlinks are not followed.
+func createOutput(outFile string, data []byte, perm os.FileMode, force bool) error {
+
+	if outFile == "" || outFile == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	// removes a symlink itself, not its target
+	if force {
+		err := os.Remove(outFile)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	// O_EXCL also fails if outFile is a symlink
+	f, err := os.OpenFile(outFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		//nolint
+		os.Remove(outFile)
+		return err
+	}
+
+	return nil
+}
+
+// readInput reads inFile, or stdin if inFile is empty or '-'
+func readInput(inFile string) ([]byte, error) {
+
+	if isStdin(inFile) {
+		return io.ReadAll(os.Stdin)
+	}
+
+	return os.ReadFile(inFile)
+}
+
+// isStdin returns true if inFile is read from stdin by readInput
+func isStdin(inFile string) bool {
+	return inFile == "" || inFile == "-"
+}
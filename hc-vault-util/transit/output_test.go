@@ -0,0 +1,101 @@
+package transit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateOutput(t *testing.T) {
+
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.pem")
+
+	err := createOutput(out, []byte("first"), 0600, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("permission = %o, want 600", perm)
+	}
+
+	// existing file
+	err = createOutput(out, []byte("second"), 0600, false)
+	if err == nil {
+		t.Error("createOutput of existing file succeeded, want error")
+	}
+	assertFileContent(t, out, "first")
+
+	err = createOutput(out, []byte("second"), 0600, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFileContent(t, out, "second")
+}
+
+func TestCreateOutputSymlink(t *testing.T) {
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+	err := os.WriteFile(target, []byte("target"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	link := filepath.Join(dir, "link")
+	err = os.Symlink(target, link)
+	if err != nil {
+		t.Skipf("symlink: %v", err)
+	}
+
+	err = createOutput(link, []byte("data"), 0600, false)
+	if err == nil {
+		t.Error("createOutput of symlink succeeded, want error")
+	}
+
+	// replaces the symlink, not its target
+	err = createOutput(link, []byte("data"), 0600, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFileContent(t, target, "target")
+	assertFileContent(t, link, "data")
+
+	info, err := os.Lstat(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		t.Error("output is still a symlink")
+	}
+
+	// dangling symlink
+	dangling := filepath.Join(dir, "dangling")
+	err = os.Symlink(filepath.Join(dir, "missing"), dangling)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = createOutput(dangling, []byte("data"), 0600, false)
+	if err == nil {
+		t.Error("createOutput of dangling symlink succeeded, want error")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "missing")); err == nil {
+		t.Error("createOutput created the dangling symlink target")
+	}
+}
+
+func assertFileContent(t *testing.T, file, want string) {
+	t.Helper()
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Errorf("%s = %q, want %q", file, data, want)
+	}
+}
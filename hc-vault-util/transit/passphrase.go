@@ -0,0 +1,74 @@
+package transit
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// PassphraseSource describes where to read the passphrase
+// protecting an encrypted private key.
+//
+// Sources are checked in order: env variable, file, then
+// interactive prompt (only if stdin is a terminal).
+type PassphraseSource struct {
+	// name of the env variable holding the passphrase
+	Env string
+	// path to a file holding the passphrase
+	File string
+
+	// passphrase read from the source (cached)
+	passphrase []byte
+}
+
+// NewPassphraseSource returns a passphrase source reading from env
+// variable 'env' or file 'file', and falling back to an interactive prompt
+func NewPassphraseSource(env, file string) *PassphraseSource {
+	return &PassphraseSource{
+		Env:  env,
+		File: file,
+	}
+}
+
+// Passphrase returns the passphrase from the configured source
+func (p *PassphraseSource) Passphrase() ([]byte, error) {
+
+	if p.passphrase != nil {
+		return p.passphrase, nil
+	}
+
+	switch {
+	case p.Env != "":
+		value, ok := os.LookupEnv(p.Env)
+		if !ok {
+			return nil, fmt.Errorf("passphrase env variable %s not set", p.Env)
+		}
+		p.passphrase = []byte(value)
+
+	case p.File != "":
+		data, err := os.ReadFile(p.File)
+		if err != nil {
+			return nil, err
+		}
+		// remove trailing new line from file
+		p.passphrase = bytes.TrimRight(data, "\r\n")
+
+	default:
+		fd := int(os.Stdin.Fd())
+		if !term.IsTerminal(fd) {
+			return nil, fmt.Errorf("no passphrase source provided and stdin is not a terminal")
+		}
+
+		fmt.Fprint(os.Stderr, "Enter passphrase: ")
+		data, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+		p.passphrase = data
+	}
+
+	return p.passphrase, nil
+}
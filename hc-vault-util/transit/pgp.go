@@ -0,0 +1,229 @@
+package transit
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	pgpecdsa "github.com/ProtonMail/go-crypto/openpgp/ecdsa"
+	"github.com/ProtonMail/go-crypto/openpgp/eddsa"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/key"
+)
+
+// hash of OpenPGP signatures per transit key type, at least the curve size for
+// ECDSA keys (sha2-256 for other key types)
+var pgpHashes = map[string]crypto.Hash{
+	"ecdsa-p384": crypto.SHA384,
+	"ecdsa-p521": crypto.SHA512,
+}
+
+// ExportPGPPublicKey writes the OpenPGP public key of version keyVersion (or latest
+// if 0) of the transit key, with userID self certified by the transit key, to
+// outFile (or stdout), ASCII armored if armor
+func (t *TransitClient) ExportPGPPublicKey(keyVersion int, userID string, armor bool, outFile string) error {
+
+	signer, pgpKey, err := t.newPGPSigner(keyVersion)
+	if err != nil {
+		return err
+	}
+
+	// as given, e.g. 'Name (comment) <email>'
+	uid := &packet.UserId{Id: userID}
+
+	// positive self certification, as gpg
+	sig := newPGPSignature(signer, pgpKey, packet.SigTypePositiveCert)
+	sig.FlagsValid = true
+	sig.FlagCertify = true
+	sig.FlagSign = true
+	// SHA256, SHA384, SHA512 (RFC 4880 section 9.4)
+	sig.PreferredHash = []uint8{8, 9, 10}
+
+	err = sig.SignUserId(uid.Id, &pgpKey.PublicKey, pgpKey, nil)
+	if err != nil {
+		t.logger.Error("Error certifying user ID with transit", "error", err)
+		return err
+	}
+
+	data, err := serializePGP(armor, openpgp.PublicKeyType, &pgpKey.PublicKey, uid, sig)
+	if err != nil {
+		return err
+	}
+
+	t.logger.Info("OpenPGP public key", "fingerprint", fmt.Sprintf("%X", pgpKey.Fingerprint), "user_id", userID)
+
+	return writeOutput(outFile, data, 0644)
+}
+
+// PGPSignFile writes the OpenPGP detached signature of file (or stdin) by version
+// keyVersion (or latest if 0) of the transit key to outFile (or stdout), ASCII
+// armored if armor
+func (t *TransitClient) PGPSignFile(file string, keyVersion int, armor bool, outFile string) error {
+
+	signer, pgpKey, err := t.newPGPSigner(keyVersion)
+	if err != nil {
+		return err
+	}
+
+	sig := newPGPSignature(signer, pgpKey, packet.SigTypeBinary)
+	h, err := sig.PrepareSign(nil)
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if !isStdin(file) {
+		f, err := os.Open(file)
+		if err != nil {
+			t.logger.Error("Error reading file", "error", err)
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	// stream large files
+	_, err = io.Copy(h, r)
+	if err != nil {
+		t.logger.Error("Error reading file", "error", err)
+		return err
+	}
+
+	err = sig.Sign(h, pgpKey, nil)
+	if err != nil {
+		t.logger.Error("Error signing with transit", "error", err)
+		return err
+	}
+
+	data, err := serializePGP(armor, openpgp.SignatureType, sig)
+	if err != nil {
+		return err
+	}
+
+	t.logger.Info("OpenPGP signature", "fingerprint", fmt.Sprintf("%X", pgpKey.Fingerprint), "version", signer.Version)
+
+	return writeOutput(outFile, data, 0644)
+}
+
+// newPGPSigner returns the signer and OpenPGP private key of version keyVersion
+// (or latest if 0) of the transit key. The OpenPGP key creation time is the
+// transit key version creation_time, so that its fingerprint is stable.
+func (t *TransitClient) newPGPSigner(keyVersion int) (*key.TransitSigner, *packet.PrivateKey, error) {
+
+	signer, err := t.newTransitSigner(keyVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, pub := range signer.Key.PublicKeys {
+		if pub.Version == signer.Version {
+
+			if pub.CreationTime.IsZero() {
+				return nil, nil, fmt.Errorf("no creation_time for version %d of transit key '%s'", pub.Version, signer.Key.Name)
+			}
+
+			pgpKey, err := newPGPPrivateKey(signer, pub.CreationTime)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			return signer, pgpKey, nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("no public key for version %d of transit key '%s'", signer.Version, signer.Key.Name)
+}
+
+// newPGPPrivateKey returns the OpenPGP v4 key of the transit signer created at
+// creationTime, as 'packet.NewSignerPrivateKey' which only accepts in memory
+// private keys. RSA and ECDSA keys sign with the signer as crypto.Signer, and
+// Ed25519 keys (legacy EdDSA, as gpg 2.2) through their curve.
+func newPGPPrivateKey(signer *key.TransitSigner, creationTime time.Time) (*packet.PrivateKey, error) {
+
+	switch pub := signer.Public().(type) {
+	case *rsa.PublicKey:
+		return &packet.PrivateKey{
+			PublicKey:  *packet.NewRSAPublicKey(creationTime, pub),
+			PrivateKey: signer,
+		}, nil
+
+	case *ecdsa.PublicKey:
+		pgpPub := pgpecdsa.NewPublicKey(pgpECDSACurve{curve: pub.Curve})
+		pgpPub.X, pgpPub.Y = pub.X, pub.Y
+
+		return &packet.PrivateKey{
+			PublicKey:  *packet.NewECDSAPublicKey(creationTime, pgpPub),
+			PrivateKey: signer,
+		}, nil
+
+	case ed25519.PublicKey:
+		pgpPub := eddsa.NewPublicKey(pgpEd25519Curve{signer: signer})
+		pgpPub.X = pub
+
+		return packet.NewEdDSAPrivateKey(creationTime, eddsa.NewPrivateKey(*pgpPub)), nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type '%s' for OpenPGP", signer.Key.Type)
+}
+
+// newPGPSignature returns a v4 sigType signature by pgpKey, the OpenPGP key of
+// signer, created now
+func newPGPSignature(signer *key.TransitSigner, pgpKey *packet.PrivateKey, sigType packet.SignatureType) *packet.Signature {
+
+	hash := crypto.SHA256
+	if h, ok := pgpHashes[signer.Key.Type]; ok {
+		hash = h
+	}
+
+	return &packet.Signature{
+		Version:      4,
+		SigType:      sigType,
+		PubKeyAlgo:   pgpKey.PubKeyAlgo,
+		Hash:         hash,
+		CreationTime: time.Now(),
+		IssuerKeyId:  &pgpKey.KeyId,
+	}
+}
+
+// serializePGP returns the serialized packets, ASCII armored as blockType if armored
+func serializePGP(armored bool, blockType string, packets ...interface{ Serialize(io.Writer) error }) ([]byte, error) {
+
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+
+	var aw io.WriteCloser
+	if armored {
+		var err error
+		aw, err = armor.Encode(&buf, blockType, nil)
+		if err != nil {
+			return nil, err
+		}
+		w = aw
+	}
+
+	for _, p := range packets {
+		err := p.Serialize(w)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if aw != nil {
+		err := aw.Close()
+		if err != nil {
+			return nil, err
+		}
+		// armor.Encode does not end the last line
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,143 @@
+package transit
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// go-crypto only builds OpenPGP ECDSA and EdDSA keys from its own curves, which
+// hold the private key operations. The curves below are found by name by
+// go-crypto (as 'ecc.FindByCurve') for the OID of the key, and keep the private
+// key in transit.
+
+var errPGPTransitPrivateKey = fmt.Errorf("OpenPGP private key is a transit key")
+
+// pgpECDSACurve OpenPGP ECDSA curve of a transit public key, signatures are made
+// by the transit signer as crypto.Signer
+type pgpECDSACurve struct {
+	curve elliptic.Curve
+}
+
+// GetCurveName returns the go-crypto curve name (e.g. 'P-256')
+func (c pgpECDSACurve) GetCurveName() string {
+	return c.curve.Params().Name
+}
+
+// MarshalIntegerPoint returns the uncompressed point
+func (c pgpECDSACurve) MarshalIntegerPoint(x, y *big.Int) []byte {
+	//nolint
+	return elliptic.Marshal(c.curve, x, y)
+}
+
+// UnmarshalIntegerPoint returns the uncompressed point coordinates
+func (c pgpECDSACurve) UnmarshalIntegerPoint(point []byte) (x, y *big.Int) {
+	//nolint
+	return elliptic.Unmarshal(c.curve, point)
+}
+
+func (c pgpECDSACurve) MarshalIntegerSecret(d *big.Int) []byte {
+	return nil
+}
+
+func (c pgpECDSACurve) UnmarshalIntegerSecret(d []byte) *big.Int {
+	return nil
+}
+
+func (c pgpECDSACurve) GenerateECDSA(rand io.Reader) (x, y, secret *big.Int, err error) {
+	return nil, nil, nil, errPGPTransitPrivateKey
+}
+
+func (c pgpECDSACurve) Sign(rand io.Reader, x, y, d *big.Int, hash []byte) (r, s *big.Int, err error) {
+	return nil, nil, errPGPTransitPrivateKey
+}
+
+// Verify returns true if r, s is the signature of hash by the public key x, y
+func (c pgpECDSACurve) Verify(x, y *big.Int, hash []byte, r, s *big.Int) bool {
+	return ecdsa.Verify(&ecdsa.PublicKey{Curve: c.curve, X: x, Y: y}, hash, r, s)
+}
+
+func (c pgpECDSACurve) ValidateECDSA(x, y *big.Int, secret []byte) error {
+	return errPGPTransitPrivateKey
+}
+
+// pgpEd25519Curve OpenPGP legacy EdDSA Ed25519 curve of a transit public key,
+// signing with the transit signer
+type pgpEd25519Curve struct {
+	signer crypto.Signer
+}
+
+// GetCurveName returns the go-crypto curve name
+func (c pgpEd25519Curve) GetCurveName() string {
+	return "ed25519"
+}
+
+// MarshalBytePoint returns the native point prefixed with 0x40
+func (c pgpEd25519Curve) MarshalBytePoint(x []byte) []byte {
+	return append([]byte{0x40}, x...)
+}
+
+// UnmarshalBytePoint returns the native point of the 0x40 prefixed point
+func (c pgpEd25519Curve) UnmarshalBytePoint(point []byte) []byte {
+	if len(point) != ed25519.PublicKeySize+1 || point[0] != 0x40 {
+		return nil
+	}
+	return point[1:]
+}
+
+func (c pgpEd25519Curve) MarshalByteSecret(d []byte) []byte {
+	return nil
+}
+
+func (c pgpEd25519Curve) UnmarshalByteSecret(d []byte) []byte {
+	return nil
+}
+
+// MarshalSignature returns R and S of the signature
+func (c pgpEd25519Curve) MarshalSignature(sig []byte) (r, s []byte) {
+	return sig[:32], sig[32:]
+}
+
+// UnmarshalSignature returns the signature of R and S, without leading zeroes
+func (c pgpEd25519Curve) UnmarshalSignature(r, s []byte) []byte {
+	if len(r) > 32 || len(s) > 32 {
+		return nil
+	}
+
+	sig := make([]byte, ed25519.SignatureSize)
+	copy(sig[32-len(r):32], r)
+	copy(sig[ed25519.SignatureSize-len(s):], s)
+	return sig
+}
+
+func (c pgpEd25519Curve) GenerateEdDSA(rand io.Reader) (pub, priv []byte, err error) {
+	return nil, nil, errPGPTransitPrivateKey
+}
+
+// Sign returns the transit signature of message (the OpenPGP digest)
+func (c pgpEd25519Curve) Sign(publicKey, privateKey, message []byte) ([]byte, error) {
+
+	sig, err := c.signer.Sign(rand.Reader, message, crypto.Hash(0))
+	if err != nil {
+		return nil, err
+	}
+	if len(sig) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("invalid Ed25519 signature length %d", len(sig))
+	}
+
+	return sig, nil
+}
+
+// Verify returns true if sig is the signature of message by publicKey
+func (c pgpEd25519Curve) Verify(publicKey, message, sig []byte) bool {
+	return ed25519.Verify(publicKey, message, sig)
+}
+
+func (c pgpEd25519Curve) ValidateEdDSA(publicKey, privateKey []byte) error {
+	return errPGPTransitPrivateKey
+}
@@ -0,0 +1,114 @@
+package transit
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/internal/transittest"
+)
+
+// TestPGPRoundTrip checks armored public keys and detached signatures of transit
+// keys with the go-crypto OpenPGP implementation
+func TestPGPRoundTrip(t *testing.T) {
+
+	userID := "Transit Key <transit@example.com>"
+	data := []byte("hello\n")
+
+	for _, keyType := range []string{"rsa-2048", "ecdsa-p256", "ecdsa-p384", "ecdsa-p521", "ed25519"} {
+		t.Run(keyType, func(t *testing.T) {
+
+			k := transittest.GenerateKey(t, keyType, 2)
+			tc := newTestTransitClient(t, "pgp", k)
+
+			dir := t.TempDir()
+			keyFile := filepath.Join(dir, "key.asc")
+			sigFile := filepath.Join(dir, "data.sig")
+			dataFile := writeTestFile(t, "data", string(data))
+
+			err := tc.ExportPGPPublicKey(1, userID, true, keyFile)
+			if err != nil {
+				t.Fatalf("ExportPGPPublicKey: %v", err)
+			}
+			err = tc.PGPSignFile(dataFile, 1, true, sigFile)
+			if err != nil {
+				t.Fatalf("PGPSignFile: %v", err)
+			}
+			if k.LastSignVersion != 1 {
+				t.Errorf("signed with version %d, want 1", k.LastSignVersion)
+			}
+
+			armoredKey, err := os.ReadFile(keyFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			armoredSig, err := os.ReadFile(sigFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// parsing checks the user ID self certification
+			keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(armoredKey))
+			if err != nil {
+				t.Fatalf("ReadArmoredKeyRing: %v\n%s", err, armoredKey)
+			}
+			if len(keyring) != 1 {
+				t.Fatalf("keyring has %d keys, want 1", len(keyring))
+			}
+
+			entity := keyring[0]
+			created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			if !entity.PrimaryKey.CreationTime.Equal(created) {
+				t.Errorf("creation time = %s, want %s", entity.PrimaryKey.CreationTime, created)
+			}
+			ident, ok := entity.Identities[userID]
+			if !ok {
+				t.Fatalf("missing user ID %q", userID)
+			}
+			if sig := ident.SelfSignature; sig.SigType != packet.SigTypePositiveCert || !sig.FlagCertify || !sig.FlagSign {
+				t.Errorf("self signature type %d, certify %t, sign %t", sig.SigType, sig.FlagCertify, sig.FlagSign)
+			}
+
+			// legacy EdDSA as gpg 2.2
+			if keyType == "ed25519" && entity.PrimaryKey.PubKeyAlgo != packet.PubKeyAlgoEdDSA {
+				t.Errorf("public key algorithm = %d, want %d", entity.PrimaryKey.PubKeyAlgo, packet.PubKeyAlgoEdDSA)
+			}
+
+			signerEntity, err := openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(data), bytes.NewReader(armoredSig), nil)
+			if err != nil {
+				t.Fatalf("CheckArmoredDetachedSignature: %v\n%s", err, armoredSig)
+			}
+			if signerEntity != entity {
+				t.Error("signature is not from the public key")
+			}
+
+			// tampered data
+			_, err = openpgp.CheckArmoredDetachedSignature(keyring, io.MultiReader(bytes.NewReader(data), bytes.NewReader([]byte("x"))), bytes.NewReader(armoredSig), nil)
+			if err == nil {
+				t.Error("signature of tampered data verified")
+			}
+
+			// the fingerprint of a version does not change
+			err = tc.ExportPGPPublicKey(1, userID, false, filepath.Join(dir, "key.gpg"))
+			if err != nil {
+				t.Fatalf("ExportPGPPublicKey: %v", err)
+			}
+			binaryKey, err := os.ReadFile(filepath.Join(dir, "key.gpg"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			keyring2, err := openpgp.ReadKeyRing(bytes.NewReader(binaryKey))
+			if err != nil {
+				t.Fatalf("ReadKeyRing: %v", err)
+			}
+			if !bytes.Equal(keyring2[0].PrimaryKey.Fingerprint, entity.PrimaryKey.Fingerprint) {
+				t.Errorf("fingerprint = %X, want %X", keyring2[0].PrimaryKey.Fingerprint, entity.PrimaryKey.Fingerprint)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package transit
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// ImportPKCS12 imports the private key from the PKCS12 (PFX) bundleFile into transit.
+//
+// If certKV2Path is not empty, the leaf certificate and CA chain from the bundle are
+// stored in the kv2 secret certKV2Mount/certKV2Path.
+func (t *TransitClient) ImportPKCS12(bundleFile string, password *PassphraseSource, certKV2Mount, certKV2Path string) error {
+	// read bundle file
+	data, err := os.ReadFile(bundleFile)
+	if err != nil {
+		t.logger.Error("Error reading PKCS12 bundle", "error", err)
+		return err
+	}
+
+	pass, err := password.Passphrase()
+	if err != nil {
+		t.logger.Error("Error reading PKCS12 password", "error", err)
+		return err
+	}
+
+	privKey, cert, caCerts, err := pkcs12.DecodeChain(data, string(pass))
+	if err != nil {
+		t.logger.Error("Error decoding PKCS12 bundle", "error", err)
+		return err
+	}
+
+	// make sure the bundle is consistent before importing anything
+	err = checkCertificateMatchesKey(cert, privKey)
+	if err != nil {
+		t.logger.Error("Error validating PKCS12 bundle", "error", err)
+		return err
+	}
+
+	t.logger.Info("PKCS12 bundle decoded", "subject", cert.Subject.String(), "chain_length", len(caCerts))
+
+	err = t.importPrivateKey(privKey)
+	if err != nil {
+		return err
+	}
+
+	if certKV2Path == "" {
+		return nil
+	}
+
+	err = t.writeKV2Secret(certKV2Mount, certKV2Path, certificateSecretData(t.transitMount, t.keyName, cert, caCerts))
+	if err != nil {
+		t.logger.Error("Error storing certificate in kv2", "error", err)
+		return err
+	}
+
+	t.logger.Info("Certificate stored", "mount", certKV2Mount, "path", certKV2Path)
+	return nil
+}
+
+// checkCertificateMatchesKey returns an error if cert is not issued for privKey
+func checkCertificateMatchesKey(cert *x509.Certificate, privKey crypto.PrivateKey) error {
+
+	signer, ok := privKey.(crypto.Signer)
+	if !ok {
+		return fmt.Errorf("unsupported private key type %T", privKey)
+	}
+
+	pub, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(cert.PublicKey) {
+		return fmt.Errorf("certificate '%s' does not match private key", cert.Subject.String())
+	}
+
+	return nil
+}
+
+// certificateSecretData returns the kv2 secret data for cert and its chain
+func certificateSecretData(transitMount, keyName string, cert *x509.Certificate, caCerts []*x509.Certificate) map[string]interface{} {
+
+	var chain bytes.Buffer
+	for _, c := range caCerts {
+		//nolint
+		pem.Encode(&chain, &pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})
+	}
+
+	return map[string]interface{}{
+		"certificate":   string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})),
+		"ca_chain":      chain.String(),
+		"subject":       cert.Subject.String(),
+		"serial_number": cert.SerialNumber.String(),
+		"not_after":     cert.NotAfter.UTC().Format(time.RFC3339),
+		"transit_mount": transitMount,
+		"transit_key":   keyName,
+	}
+}
@@ -0,0 +1,154 @@
+package transit
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/internal/transittest"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// newTestCertificate returns a certificate of pub issued by issuer, or
+// self-signed if issuer is nil
+func newTestCertificate(t *testing.T, commonName string, pub crypto.PublicKey, issuer *x509.Certificate, issuerKey crypto.Signer) *x509.Certificate {
+	t.Helper()
+
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  issuer == nil,
+	}
+	if issuer == nil {
+		issuer = tpl
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, issuer, pub, issuerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestImportPKCS12(t *testing.T) {
+
+	caKey := transittest.GenerateSigner(t, "ecdsa-p256")
+	caCert := newTestCertificate(t, "Test CA", caKey.Public(), nil, caKey)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		key      crypto.Signer
+		certKey  crypto.Signer
+		password string
+		wantType string
+		// substring of the error, no error if empty
+		wantErr string
+	}{
+		{name: "RSA", key: rsaKey, certKey: rsaKey, password: "secret", wantType: "rsa-2048"},
+		{name: "ECDSA", key: ecKey, certKey: ecKey, password: "secret", wantType: "ecdsa-p384"},
+		{name: "ed25519", key: edKey, certKey: edKey, password: "secret", wantType: "ed25519"},
+		{name: "key and certificate mismatch", key: ecKey, certKey: otherKey, password: "secret", wantErr: "does not match private key"},
+		{name: "wrong password", key: ecKey, certKey: ecKey, password: "wrong", wantErr: "password incorrect"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+
+			cert := newTestCertificate(t, "Test Leaf", tc.certKey.Public(), caCert, caKey)
+			pfx, err := pkcs12.Modern.Encode(tc.key, cert, []*x509.Certificate{caCert}, "secret")
+			if err != nil {
+				t.Fatal(err)
+			}
+			bundleFile := writeTestFile(t, "bundle.p12", string(pfx))
+			t.Setenv(testPassphraseEnv, tc.password)
+
+			srv := transittest.NewServer(t, map[string]*transittest.Key{})
+			client := newTestServerClient(srv, "imported")
+
+			err = client.ImportPKCS12(bundleFile, NewPassphraseSource(testPassphraseEnv, ""), "", "")
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("ImportPKCS12 error = %v, want %s", err, tc.wantErr)
+				}
+				if srv.Key("imported") != nil {
+					t.Error("key imported despite the error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ImportPKCS12: %v", err)
+			}
+
+			k := srv.Key("imported")
+			if k == nil || len(k.Imported) != 1 {
+				t.Fatal("key not imported in transit")
+			}
+			if k.Type != tc.wantType {
+				t.Errorf("key type = %s, want %s", k.Type, tc.wantType)
+			}
+
+			// transit imports the PKCS#8 private key
+			want, err := x509.MarshalPKCS8PrivateKey(tc.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(k.Imported[0], want) {
+				t.Error("imported key material is not the bundle private key")
+			}
+		})
+	}
+}
+
+func TestCheckCertificateMatchesKey(t *testing.T) {
+
+	key := transittest.GenerateSigner(t, "ecdsa-p256")
+	other := transittest.GenerateSigner(t, "ed25519")
+	cert := newTestCertificate(t, "Test", key.Public(), nil, key)
+
+	err := checkCertificateMatchesKey(cert, key)
+	if err != nil {
+		t.Errorf("checkCertificateMatchesKey: %v", err)
+	}
+	err = checkCertificateMatchesKey(cert, other)
+	if err == nil {
+		t.Error("certificate matches another key")
+	}
+	err = checkCertificateMatchesKey(cert, "not a key")
+	if err == nil {
+		t.Error("certificate matches an unsupported key type")
+	}
+}
@@ -0,0 +1,90 @@
+package transit
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/youmark/pkcs8"
+)
+
+const (
+	pemTypePKCS8          = "PRIVATE KEY"
+	pemTypeEncryptedPKCS8 = "ENCRYPTED PRIVATE KEY"
+	pemTypePKCS1          = "RSA PRIVATE KEY"
+	pemTypeSEC1           = "EC PRIVATE KEY"
+	pemTypeECParameters   = "EC PARAMETERS"
+)
+
+// parsePrivateKeyPEM parses the first private key found in the PEM data.
+//
+// Supported formats:
+//   - PKCS8 ('PRIVATE KEY')
+//   - encrypted PKCS8 ('ENCRYPTED PRIVATE KEY')
+//   - PKCS1 RSA ('RSA PRIVATE KEY'), optionally with legacy PEM encryption
+//   - SEC1 EC ('EC PRIVATE KEY'), optionally with legacy PEM encryption
+//
+// passphrase is only read if the key is encrypted.
+func parsePrivateKeyPEM(data []byte, passphrase *PassphraseSource) (crypto.PrivateKey, error) {
+
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, fmt.Errorf("Error Decoding PEM file, no private key found")
+		}
+
+		switch block.Type {
+		case pemTypeECParameters:
+			// openssl ecparam -genkey outputs the curve params
+			// before the key itself
+			continue
+
+		case pemTypePKCS8:
+			return x509.ParsePKCS8PrivateKey(block.Bytes)
+
+		case pemTypeEncryptedPKCS8:
+			pass, err := passphrase.Passphrase()
+			if err != nil {
+				return nil, err
+			}
+			return pkcs8.ParsePKCS8PrivateKey(block.Bytes, pass)
+
+		case pemTypePKCS1, pemTypeSEC1:
+			der, err := decryptLegacyPEMBlock(block, passphrase)
+			if err != nil {
+				return nil, err
+			}
+
+			if block.Type == pemTypePKCS1 {
+				return x509.ParsePKCS1PrivateKey(der)
+			}
+			return x509.ParseECPrivateKey(der)
+
+		default:
+			return nil, fmt.Errorf("unsupported PEM block type '%s'", block.Type)
+		}
+	}
+}
+
+// decryptLegacyPEMBlock returns the DER bytes of block, decrypting them
+// if the block is encrypted with the legacy openssl 'Proc-Type: 4,ENCRYPTED' headers
+func decryptLegacyPEMBlock(block *pem.Block, passphrase *PassphraseSource) ([]byte, error) {
+
+	// NOTE: legacy PEM encryption is insecure by design, but still
+	//       widely used by 'openssl rsa -des3' and friends
+	//nolint
+	if !x509.IsEncryptedPEMBlock(block) {
+		return block.Bytes, nil
+	}
+
+	pass, err := passphrase.Passphrase()
+	if err != nil {
+		return nil, err
+	}
+
+	//nolint
+	return x509.DecryptPEMBlock(block, pass)
+}
@@ -0,0 +1,212 @@
+package transit
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/youmark/pkcs8"
+)
+
+const testPassphraseEnv = "HC_VAULT_UTIL_TEST_PASSPHRASE"
+
+// encodeTestPEM returns the PEM encoded blocks
+func encodeTestPEM(blocks ...*pem.Block) []byte {
+
+	var data []byte
+	for _, b := range blocks {
+		data = append(data, pem.EncodeToMemory(b)...)
+	}
+	return data
+}
+
+// legacyEncryptedPEM returns der encrypted with the legacy openssl PEM encryption
+func legacyEncryptedPEM(t *testing.T, blockType string, der []byte, passphrase string) *pem.Block {
+	t.Helper()
+
+	//nolint
+	block, err := x509.EncryptPEMBlock(rand.Reader, blockType, der, []byte(passphrase), x509.PEMCipherAES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return block
+}
+
+func TestParsePrivateKeyPEM(t *testing.T) {
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkcs8DER := func(priv crypto.PrivateKey) []byte {
+		der, err := x509.MarshalPKCS8PrivateKey(priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der
+	}
+	encryptedPKCS8DER := func(priv crypto.PrivateKey, passphrase string) []byte {
+		der, err := pkcs8.MarshalPrivateKey(priv, []byte(passphrase), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der
+	}
+	sec1DER, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs1DER := x509.MarshalPKCS1PrivateKey(rsaKey)
+
+	// 'openssl ecparam -name prime256v1 -genkey' output
+	p256Params, err := asn1.Marshal(asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		// passphrase env variable value, unset if empty
+		passphrase string
+		want       crypto.Signer
+		wantErr    bool
+		// substring of the error, if set
+		errContains string
+	}{
+		{
+			name: "PKCS#8 RSA",
+			data: encodeTestPEM(&pem.Block{Type: pemTypePKCS8, Bytes: pkcs8DER(rsaKey)}),
+			want: rsaKey,
+		},
+		{
+			name: "PKCS#8 ed25519",
+			data: encodeTestPEM(&pem.Block{Type: pemTypePKCS8, Bytes: pkcs8DER(edKey)}),
+			want: edKey,
+		},
+		{
+			name: "PKCS#1",
+			data: encodeTestPEM(&pem.Block{Type: pemTypePKCS1, Bytes: pkcs1DER}),
+			want: rsaKey,
+		},
+		{
+			name: "SEC1",
+			data: encodeTestPEM(&pem.Block{Type: pemTypeSEC1, Bytes: sec1DER}),
+			want: ecKey,
+		},
+		{
+			name: "SEC1 with EC PARAMETERS",
+			data: encodeTestPEM(&pem.Block{Type: pemTypeECParameters, Bytes: p256Params}, &pem.Block{Type: pemTypeSEC1, Bytes: sec1DER}),
+			want: ecKey,
+		},
+		{
+			name:       "encrypted PKCS#8 ECDSA",
+			data:       encodeTestPEM(&pem.Block{Type: pemTypeEncryptedPKCS8, Bytes: encryptedPKCS8DER(ecKey, "secret")}),
+			passphrase: "secret",
+			want:       ecKey,
+		},
+		{
+			name:       "encrypted PKCS#8 RSA",
+			data:       encodeTestPEM(&pem.Block{Type: pemTypeEncryptedPKCS8, Bytes: encryptedPKCS8DER(rsaKey, "secret")}),
+			passphrase: "secret",
+			want:       rsaKey,
+		},
+		{
+			name:        "encrypted PKCS#8 wrong passphrase",
+			data:        encodeTestPEM(&pem.Block{Type: pemTypeEncryptedPKCS8, Bytes: encryptedPKCS8DER(ecKey, "secret")}),
+			passphrase:  "wrong",
+			wantErr:     true,
+			errContains: "incorrect password",
+		},
+		{
+			name:        "encrypted PKCS#8 without passphrase",
+			data:        encodeTestPEM(&pem.Block{Type: pemTypeEncryptedPKCS8, Bytes: encryptedPKCS8DER(ecKey, "secret")}),
+			wantErr:     true,
+			errContains: testPassphraseEnv + " not set",
+		},
+		{
+			name:       "legacy encrypted PKCS#1",
+			data:       encodeTestPEM(legacyEncryptedPEM(t, pemTypePKCS1, pkcs1DER, "secret")),
+			passphrase: "secret",
+			want:       rsaKey,
+		},
+		{
+			name:       "legacy encrypted SEC1",
+			data:       encodeTestPEM(legacyEncryptedPEM(t, pemTypeSEC1, sec1DER, "secret")),
+			passphrase: "secret",
+			want:       ecKey,
+		},
+		{
+			// may also fail parsing the decrypted key, if its padding is valid
+			name:       "legacy encrypted SEC1 wrong passphrase",
+			data:       encodeTestPEM(legacyEncryptedPEM(t, pemTypeSEC1, sec1DER, "secret")),
+			passphrase: "wrong",
+			wantErr:    true,
+		},
+		{
+			name:    "unsupported block",
+			data:    encodeTestPEM(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{0x30, 0x00}}),
+			wantErr: true,
+		},
+		{
+			name:    "only EC PARAMETERS",
+			data:    encodeTestPEM(&pem.Block{Type: pemTypeECParameters, Bytes: p256Params}),
+			wantErr: true,
+		},
+		{
+			name:    "not PEM",
+			data:    pkcs1DER,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+
+			// unencrypted keys do not read the passphrase
+			if tc.passphrase != "" {
+				t.Setenv(testPassphraseEnv, tc.passphrase)
+			}
+
+			priv, err := parsePrivateKeyPEM(tc.data, NewPassphraseSource(testPassphraseEnv, ""))
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("parsePrivateKeyPEM succeeded, want error")
+				}
+				if !strings.Contains(err.Error(), tc.errContains) {
+					t.Errorf("parsePrivateKeyPEM error = %v, want %s", err, tc.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePrivateKeyPEM: %v", err)
+			}
+
+			signer, ok := priv.(crypto.Signer)
+			if !ok {
+				t.Fatalf("private key type %T is not a signer", priv)
+			}
+			pub, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+			if !ok || !pub.Equal(tc.want.Public()) {
+				t.Error("parsed private key differs from the generated key")
+			}
+		})
+	}
+}
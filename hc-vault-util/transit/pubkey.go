@@ -0,0 +1,150 @@
+package transit
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/jose"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/key"
+	"golang.org/x/crypto/ssh"
+)
+
+// PublicKeyFormats supported output formats of ExportPublicKey
+var PublicKeyFormats = []string{"pem", "der", "ssh", "jwk"}
+
+// ExportPublicKey writes version keyVersion (or latest if 0), or every version if
+// all, of the transit public key in format (pem, der, ssh or jwk) to outFile (or
+// stdout), and logs the SHA-256 fingerprints of every version
+func (t *TransitClient) ExportPublicKey(keyVersion int, all bool, format, outFile string) error {
+
+	k, err := t.syncTransitKey()
+	if err != nil {
+		return err
+	}
+
+	if keyVersion == 0 {
+		keyVersion = k.Version
+	}
+
+	var selected []*key.TransitPublicKey
+	for _, pub := range k.PublicKeys {
+
+		spkiFingerprint, sshFingerprint, err := publicKeyFingerprints(pub.PublicKey)
+		if err != nil {
+			return err
+		}
+		t.logger.Info("Public key", "name", k.Name, "version", pub.Version, "type", k.Type, "sha256", spkiFingerprint, "ssh_sha256", sshFingerprint)
+
+		if all || pub.Version == keyVersion {
+			selected = append(selected, pub)
+		}
+	}
+
+	if len(selected) == 0 {
+		return &key.KeyVersionError{Name: k.Name, Version: keyVersion, MinVersion: k.MinVersion, LatestVersion: k.Version}
+	}
+
+	data, err := encodePublicKeys(k, selected, format)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(outFile, data, 0644)
+}
+
+// encodePublicKeys returns the public keys of transit key k in format
+func encodePublicKeys(k *key.VaultTransitKey, pubs []*key.TransitPublicKey, format string) ([]byte, error) {
+
+	var out bytes.Buffer
+	switch format {
+	case "pem":
+		for _, pub := range pubs {
+			der, err := x509.MarshalPKIXPublicKey(pub.PublicKey)
+			if err != nil {
+				return nil, err
+			}
+			//nolint
+			pem.Encode(&out, &pem.Block{Type: "PUBLIC KEY", Bytes: der})
+		}
+
+	case "der":
+		// DER has no framing for several keys
+		if len(pubs) != 1 {
+			return nil, fmt.Errorf("der format only supports a single version")
+		}
+		return x509.MarshalPKIXPublicKey(pubs[0].PublicKey)
+
+	case "ssh":
+		for _, pub := range pubs {
+			sshPub, err := ssh.NewPublicKey(pub.PublicKey)
+			if err != nil {
+				return nil, err
+			}
+			// authorized_keys line with '<name>:v<version>' comment
+			line := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPub)))
+			fmt.Fprintf(&out, "%s %s\n", line, jose.KeyID(k.Name, pub.Version))
+		}
+
+	case "jwk":
+		alg, err := jose.DefaultAlgorithm(k.Type)
+		if err != nil {
+			return nil, err
+		}
+
+		jwks := &jose.JWKS{}
+		for _, pub := range pubs {
+			jwk, err := jose.NewJWK(pub.PublicKey, jose.KeyID(k.Name, pub.Version), alg, "sig")
+			if err != nil {
+				return nil, err
+			}
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+
+		// single JWK, or JWKS for several versions
+		var v interface{} = jwks
+		if len(jwks.Keys) == 1 {
+			v = jwks.Keys[0]
+		}
+
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		out.Write(data)
+		out.WriteByte('\n')
+
+	default:
+		return nil, fmt.Errorf("unsupported format '%s', must be one of %s", format, strings.Join(PublicKeyFormats, ", "))
+	}
+
+	return out.Bytes(), nil
+}
+
+// publicKeyFingerprints returns the hex SHA-256 of the PKIX DER public key, and
+// the OpenSSH SHA-256 fingerprint
+func publicKeyFingerprints(pub crypto.PublicKey) (string, string, error) {
+
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", "", err
+	}
+
+	sum := sha256.Sum256(der)
+	hexSum := make([]string, len(sum))
+	for i, b := range sum {
+		hexSum[i] = fmt.Sprintf("%02X", b)
+	}
+
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return "", "", err
+	}
+
+	return strings.Join(hexSum, ":"), ssh.FingerprintSHA256(sshPub), nil
+}
@@ -0,0 +1,163 @@
+package transit
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+	"gopkg.in/yaml.v3"
+)
+
+// revocationReasons RFC 5280 CRL reason codes by name
+var revocationReasons = map[string]int{
+	"unspecified":          ocsp.Unspecified,
+	"keycompromise":        ocsp.KeyCompromise,
+	"cacompromise":         ocsp.CACompromise,
+	"affiliationchanged":   ocsp.AffiliationChanged,
+	"superseded":           ocsp.Superseded,
+	"cessationofoperation": ocsp.CessationOfOperation,
+	"certificatehold":      ocsp.CertificateHold,
+	"removefromcrl":        ocsp.RemoveFromCRL,
+	"privilegewithdrawn":   ocsp.PrivilegeWithdrawn,
+	"aacompromise":         ocsp.AACompromise,
+}
+
+// RevocationList is the list of certificates revoked by a CA, and of the
+// valid certificates it issued (for OCSP)
+//
+// YAML format:
+//
+//	revoked:
+//	  - serial: "0x1f3a..."  # decimal, 0x prefixed hex or ':' separated hex
+//	    revoked_at: 2024-01-31T12:00:00Z
+//	    reason: keyCompromise  # optional, RFC 5280 reason name
+//	issued:  # optional, serials of the valid certificates
+//	  - "0x2b4c..."
+type RevocationList struct {
+	Revoked []RevokedCertificate `yaml:"revoked"`
+	Issued  []string             `yaml:"issued"`
+
+	issuedSerials []*big.Int
+}
+
+// RevokedCertificate is a certificate revoked by a CA
+type RevokedCertificate struct {
+	Serial    string    `yaml:"serial"`
+	RevokedAt time.Time `yaml:"revoked_at"`
+	Reason    string    `yaml:"reason"`
+
+	serialNumber *big.Int
+	reasonCode   int
+}
+
+// LoadRevocationList reads and validates the YAML revocation list file
+func LoadRevocationList(file string) (*RevocationList, error) {
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &RevocationList{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	err = dec.Decode(l)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing revocation list '%s': %w", file, err)
+	}
+
+	for i := range l.Revoked {
+		err = l.Revoked[i].parse()
+		if err != nil {
+			return nil, fmt.Errorf("invalid revoked certificate #%d: %w", i+1, err)
+		}
+	}
+
+	for _, v := range l.Issued {
+		serial, err := ParseSerialNumber(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid issued certificate: %w", err)
+		}
+		if l.Find(serial) != nil {
+			return nil, fmt.Errorf("issued certificate '%s' is also revoked", v)
+		}
+		l.issuedSerials = append(l.issuedSerials, serial)
+	}
+
+	return l, nil
+}
+
+// Find returns the revoked certificate with serial number, or nil if not revoked
+func (l *RevocationList) Find(serial *big.Int) *RevokedCertificate {
+
+	for i := range l.Revoked {
+		if l.Revoked[i].serialNumber.Cmp(serial) == 0 {
+			return &l.Revoked[i]
+		}
+	}
+
+	return nil
+}
+
+// IsIssued returns true if serial is a valid certificate of the list
+func (l *RevocationList) IsIssued(serial *big.Int) bool {
+
+	for _, s := range l.issuedSerials {
+		if s.Cmp(serial) == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parse validates the entry and sets its serial number and reason code
+func (r *RevokedCertificate) parse() error {
+
+	serial, err := ParseSerialNumber(r.Serial)
+	if err != nil {
+		return err
+	}
+	r.serialNumber = serial
+
+	if r.RevokedAt.IsZero() {
+		return fmt.Errorf("missing revoked_at for serial '%s'", r.Serial)
+	}
+
+	if r.Reason != "" {
+		code, ok := revocationReasons[strings.ToLower(r.Reason)]
+		if !ok {
+			return fmt.Errorf("unknown revocation reason '%s'", r.Reason)
+		}
+		r.reasonCode = code
+	}
+
+	return nil
+}
+
+// ParseSerialNumber parses a certificate serial number in decimal,
+// 0x prefixed hex, or ':' separated hex (openssl) format
+func ParseSerialNumber(s string) (*big.Int, error) {
+
+	digits := strings.TrimSpace(s)
+	base := 10
+	switch {
+	case strings.Contains(digits, ":"):
+		digits = strings.ReplaceAll(digits, ":", "")
+		base = 16
+	case strings.HasPrefix(strings.ToLower(digits), "0x"):
+		digits = digits[2:]
+		base = 16
+	}
+
+	serial, ok := new(big.Int).SetString(digits, base)
+	if !ok || serial.Sign() < 0 {
+		return nil, fmt.Errorf("invalid serial number '%s'", s)
+	}
+
+	return serial, nil
+}
@@ -0,0 +1,167 @@
+package transit
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/mail"
+	"net/url"
+	"time"
+
+	"github.com/cloudflare/cfssl/config"
+	"github.com/cloudflare/cfssl/csr"
+	"github.com/cloudflare/cfssl/log"
+)
+
+// backdate of certificate NotBefore to tolerate clock skew (same as cfssl)
+const certificateBackdate = 5 * time.Minute
+
+// CertificateOptions are the X.509 options of a certificate signed with a transit key
+type CertificateOptions struct {
+	// validity period from now
+	Expiry time.Duration
+	// cfssl key usage names (e.g. 'cert sign')
+	KeyUsage []string
+	// cfssl extended key usage names (e.g. 'server auth')
+	ExtKeyUsage []string
+
+	// basic constraints
+	IsCA bool
+	// max path length for CA, or -1 for unlimited
+	MaxPathLen int
+}
+
+// SelfSign generates a self-signed certificate for the cfssl CSR JSON signed
+// by version keyVersion (or latest if 0) of the transit key, and writes it PEM
+// encoded to outFile (or stdout)
+func (t *TransitClient) SelfSign(cfsslCSRFile string, keyVersion int, opts CertificateOptions, outFile string) error {
+
+	req, err := t.loadCfsslCSR(cfsslCSRFile)
+	if err != nil {
+		return err
+	}
+
+	tpl, err := selfSignedTemplate(req, opts)
+	if err != nil {
+		t.logger.Error("Error building certificate template", "error", err)
+		return err
+	}
+
+	// quite cfssl logger
+	log.Level = log.LevelCritical
+
+	signer, err := t.newTransitSigner(keyVersion)
+	if err != nil {
+		return err
+	}
+
+	// self-signed: template is also the parent
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, signer.Public(), signer)
+	if err != nil {
+		return err
+	}
+
+	t.logger.Info("PEM encoded self-signed certificate", "subject", tpl.Subject.String(), "serial", tpl.SerialNumber.String(), "not_after", tpl.NotAfter)
+
+	return writeOutput(outFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+}
+
+// selfSignedTemplate returns the certificate template for the cfssl CSR and options
+func selfSignedTemplate(req *csr.CertificateRequest, opts CertificateOptions) (*x509.Certificate, error) {
+
+	subject, err := req.Name()
+	if err != nil {
+		return nil, err
+	}
+
+	keyUsage, extKeyUsage, err := parseUsages(opts.KeyUsage, opts.ExtKeyUsage)
+	if err != nil {
+		return nil, err
+	}
+
+	serial, err := randomSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	tpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               subject,
+		NotBefore:             now.Add(-certificateBackdate),
+		NotAfter:              now.Add(opts.Expiry),
+		KeyUsage:              keyUsage,
+		ExtKeyUsage:           extKeyUsage,
+		BasicConstraintsValid: true,
+		IsCA:                  opts.IsCA,
+		ExtraExtensions:       req.Extensions,
+	}
+
+	if opts.IsCA && opts.MaxPathLen >= 0 {
+		tpl.MaxPathLen = opts.MaxPathLen
+		tpl.MaxPathLenZero = opts.MaxPathLen == 0
+	} else {
+		tpl.MaxPathLen = -1
+	}
+
+	tpl.DNSNames, tpl.IPAddresses, tpl.EmailAddresses, tpl.URIs = parseHosts(req.Hosts)
+
+	return tpl, nil
+}
+
+// parseUsages converts cfssl key usage and extended key usage names
+func parseUsages(keyUsageNames, extKeyUsageNames []string) (x509.KeyUsage, []x509.ExtKeyUsage, error) {
+
+	var keyUsage x509.KeyUsage
+	for _, name := range keyUsageNames {
+		ku, ok := config.KeyUsage[name]
+		if !ok {
+			return 0, nil, fmt.Errorf("unknown key usage '%s'", name)
+		}
+		keyUsage |= ku
+	}
+
+	extKeyUsage := []x509.ExtKeyUsage{}
+	for _, name := range extKeyUsageNames {
+		eku, ok := config.ExtKeyUsage[name]
+		if !ok {
+			return 0, nil, fmt.Errorf("unknown extended key usage '%s'", name)
+		}
+		extKeyUsage = append(extKeyUsage, eku)
+	}
+
+	return keyUsage, extKeyUsage, nil
+}
+
+// parseHosts splits cfssl hosts into x509 SANs (same as cfssl csr.Generate)
+func parseHosts(hosts []string) ([]string, []net.IP, []string, []*url.URL) {
+
+	var dnsNames []string
+	var ips []net.IP
+	var emails []string
+	var uris []*url.URL
+
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			ips = append(ips, ip)
+		} else if email, err := mail.ParseAddress(h); err == nil && email != nil {
+			emails = append(emails, email.Address)
+		} else if uri, err := url.ParseRequestURI(h); err == nil && uri != nil {
+			uris = append(uris, uri)
+		} else {
+			dnsNames = append(dnsNames, h)
+		}
+	}
+
+	return dnsNames, ips, emails, uris
+}
+
+// randomSerialNumber returns a random positive 159 bits certificate serial number
+func randomSerialNumber() (*big.Int, error) {
+
+	limit := new(big.Int).Lsh(big.NewInt(1), 159)
+	return rand.Int(rand.Reader, limit)
+}
@@ -0,0 +1,204 @@
+package transit
+
+import (
+	"crypto/x509"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cfssl/csr"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/internal/transittest"
+)
+
+func TestParseUsages(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		keyUsage    []string
+		extKeyUsage []string
+		wantKU      x509.KeyUsage
+		wantEKU     []x509.ExtKeyUsage
+		wantErr     bool
+	}{
+		{
+			name:    "none",
+			wantEKU: []x509.ExtKeyUsage{},
+		},
+		{
+			name:        "leaf",
+			keyUsage:    []string{"signing", "key encipherment"},
+			extKeyUsage: []string{"server auth", "client auth"},
+			wantKU:      x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+			wantEKU:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		},
+		{
+			name:     "CA",
+			keyUsage: []string{"cert sign", "crl sign"},
+			wantKU:   x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+			wantEKU:  []x509.ExtKeyUsage{},
+		},
+		{
+			name:     "unknown key usage",
+			keyUsage: []string{"server auth"},
+			wantErr:  true,
+		},
+		{
+			name:        "unknown extended key usage",
+			extKeyUsage: []string{"cert sign"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+
+			ku, eku, err := parseUsages(tc.keyUsage, tc.extKeyUsage)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("parseUsages succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseUsages: %v", err)
+			}
+			if ku != tc.wantKU {
+				t.Errorf("key usage = %d, want %d", ku, tc.wantKU)
+			}
+			if !reflect.DeepEqual(eku, tc.wantEKU) {
+				t.Errorf("extended key usage = %v, want %v", eku, tc.wantEKU)
+			}
+		})
+	}
+}
+
+func TestSelfSignedTemplate(t *testing.T) {
+
+	req := &csr.CertificateRequest{
+		CN:    "Test CA",
+		Names: []csr.Name{{O: "Test"}},
+		Hosts: []string{"ca.example.com", "10.0.0.1", "ca@example.com", "spiffe://example.com/ca"},
+	}
+
+	tests := []struct {
+		name           string
+		opts           CertificateOptions
+		wantErr        bool
+		wantMaxPathLen int
+		wantZero       bool
+	}{
+		{
+			name:           "leaf",
+			opts:           CertificateOptions{Expiry: time.Hour, KeyUsage: []string{"signing"}, ExtKeyUsage: []string{"server auth"}, MaxPathLen: 2},
+			wantMaxPathLen: -1,
+		},
+		{
+			name:           "CA unlimited path length",
+			opts:           CertificateOptions{Expiry: time.Hour, KeyUsage: []string{"cert sign"}, IsCA: true, MaxPathLen: -1},
+			wantMaxPathLen: -1,
+		},
+		{
+			name:           "CA path length 0",
+			opts:           CertificateOptions{Expiry: time.Hour, KeyUsage: []string{"cert sign"}, IsCA: true, MaxPathLen: 0},
+			wantMaxPathLen: 0,
+			wantZero:       true,
+		},
+		{
+			name:           "CA path length 2",
+			opts:           CertificateOptions{Expiry: time.Hour, KeyUsage: []string{"cert sign"}, IsCA: true, MaxPathLen: 2},
+			wantMaxPathLen: 2,
+		},
+		{
+			name:    "unknown usage",
+			opts:    CertificateOptions{Expiry: time.Hour, KeyUsage: []string{"sign everything"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+
+			tpl, err := selfSignedTemplate(req, tc.opts)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("selfSignedTemplate succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("selfSignedTemplate: %v", err)
+			}
+
+			if tpl.Subject.CommonName != "Test CA" || len(tpl.Subject.Organization) != 1 {
+				t.Errorf("subject = %s", tpl.Subject)
+			}
+			if tpl.IsCA != tc.opts.IsCA || tpl.MaxPathLen != tc.wantMaxPathLen || tpl.MaxPathLenZero != tc.wantZero {
+				t.Errorf("IsCA %t, MaxPathLen %d, MaxPathLenZero %t, want %t, %d, %t", tpl.IsCA, tpl.MaxPathLen, tpl.MaxPathLenZero, tc.opts.IsCA, tc.wantMaxPathLen, tc.wantZero)
+			}
+			if validity := tpl.NotAfter.Sub(tpl.NotBefore); validity != tc.opts.Expiry+certificateBackdate {
+				t.Errorf("validity = %s, want %s with backdate", validity, tc.opts.Expiry)
+			}
+			if len(tpl.DNSNames) != 1 || len(tpl.IPAddresses) != 1 || len(tpl.EmailAddresses) != 1 || len(tpl.URIs) != 1 {
+				t.Errorf("SANs = %v %v %v %v, want one of each", tpl.DNSNames, tpl.IPAddresses, tpl.EmailAddresses, tpl.URIs)
+			}
+			if tpl.SerialNumber.Sign() <= 0 {
+				t.Errorf("serial number %s is not positive", tpl.SerialNumber)
+			}
+		})
+	}
+}
+
+func TestSelfSign(t *testing.T) {
+
+	for _, keyType := range []string{"rsa-2048", "ecdsa-p256", "ed25519"} {
+		t.Run(keyType, func(t *testing.T) {
+
+			k := transittest.GenerateKey(t, keyType, 2)
+			tc := newTestTransitClient(t, "ca", k)
+			csrFile := writeTestFile(t, "ca-csr.json", `{"CN": "Test CA", "hosts": ["ca.example.com"]}`)
+
+			opts := CertificateOptions{
+				Expiry:     24 * time.Hour,
+				KeyUsage:   []string{"cert sign", "crl sign"},
+				IsCA:       true,
+				MaxPathLen: 0,
+			}
+			out := filepath.Join(t.TempDir(), "ca.pem")
+			err := tc.SelfSign(csrFile, 1, opts, out)
+			if err != nil {
+				t.Fatalf("SelfSign: %v", err)
+			}
+			if k.LastSignVersion != 1 {
+				t.Errorf("signed with version %d, want 1", k.LastSignVersion)
+			}
+
+			cert := readTestCertificate(t, out)
+			err = cert.CheckSignatureFrom(cert)
+			if err != nil {
+				t.Errorf("CheckSignatureFrom: %v", err)
+			}
+			if !cert.IsCA || cert.MaxPathLen != 0 || !cert.MaxPathLenZero {
+				t.Errorf("IsCA %t, MaxPathLen %d, MaxPathLenZero %t, want CA with path length 0", cert.IsCA, cert.MaxPathLen, cert.MaxPathLenZero)
+			}
+			if cert.KeyUsage != x509.KeyUsageCertSign|x509.KeyUsageCRLSign {
+				t.Errorf("key usage = %d", cert.KeyUsage)
+			}
+			if !reflect.DeepEqual(cert.PublicKey, k.Versions[0].Public()) {
+				t.Error("certificate public key is not the transit key version 1")
+			}
+		})
+	}
+}
+
+// readTestCertificate returns the PEM certificate of file
+func readTestCertificate(t *testing.T, file string) *x509.Certificate {
+	t.Helper()
+
+	certs, err := loadCertificates(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return certs[0]
+}
@@ -0,0 +1,221 @@
+package transit
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/key"
+
+	// register sha3 hashes
+	_ "golang.org/x/crypto/sha3"
+)
+
+// SignatureFormats supported detached signature formats
+var SignatureFormats = []string{"vault", "base64", "raw"}
+
+// SignFileOptions are the options of a detached file signature
+type SignFileOptions struct {
+	// transit hash_algorithm (e.g. 'sha2-256')
+	HashAlg string
+	// transit signature_algorithm for RSA keys ('pss' or 'pkcs1v15'), or default if empty
+	SigAlg string
+	// signature format, one of SignatureFormats
+	Format string
+}
+
+// SignFile hashes file (or stdin) locally and signs the digest with version
+// keyVersion (or latest if 0) of the transit key, and writes the detached
+// signature in opts.Format to outFile (or stdout)
+func (t *TransitClient) SignFile(file string, keyVersion int, opts SignFileOptions, outFile string) error {
+
+	err := checkSignatureFormat(opts.Format)
+	if err != nil {
+		return err
+	}
+
+	signer, err := t.newTransitSigner(keyVersion)
+	if err != nil {
+		return err
+	}
+	k := signer.Key
+
+	// ed25519 signs the whole message, which is not sent to transit
+	if k.Type == "ed25519" {
+		return fmt.Errorf("transit key type '%s' does not support prehashed signatures", k.Type)
+	}
+
+	sigAlg := signer.SigAlg
+	if opts.SigAlg != "" {
+		sigAlg = opts.SigAlg
+	}
+
+	digest, err := hashFile(file, opts.HashAlg)
+	if err != nil {
+		t.logger.Error("Error hashing file", "error", err)
+		return err
+	}
+
+	hash, err := key.VaultHashToCryptoHash(opts.HashAlg)
+	if err != nil {
+		return err
+	}
+
+	signer.SigAlg = sigAlg
+	sig, err := signer.Sign(rand.Reader, digest, hash)
+	if err != nil {
+		t.logger.Error("Error signing with transit", "error", err)
+		return err
+	}
+	sigVault := fmt.Sprintf("vault:v%d:%s", signer.Version, base64.StdEncoding.EncodeToString(sig))
+
+	t.logger.Info("File signed", "version", signer.Version, "hash", opts.HashAlg, "digest", fmt.Sprintf("%x", digest))
+
+	var data []byte
+	switch opts.Format {
+	case "vault":
+		data = []byte(sigVault + "\n")
+	case "base64":
+		data = []byte(base64.StdEncoding.EncodeToString(sig) + "\n")
+	case "raw":
+		data = sig
+	}
+
+	return writeOutput(outFile, data, 0644)
+}
+
+// VerifyFile hashes file (or stdin) locally and verifies the detached signature
+// sigFile in opts.Format with transit verify. The key version is read from 'vault'
+// signatures, and is keyVersion (or latest if 0) for other formats
+func (t *TransitClient) VerifyFile(file, sigFile string, keyVersion int, opts SignFileOptions) error {
+
+	err := checkSignatureFormat(opts.Format)
+	if err != nil {
+		return err
+	}
+
+	if isStdin(file) && isStdin(sigFile) {
+		return fmt.Errorf("file and signature cannot both be read from stdin")
+	}
+
+	data, err := readInput(sigFile)
+	if err != nil {
+		t.logger.Error("Error reading signature", "error", err)
+		return err
+	}
+
+	var sig []byte
+	switch opts.Format {
+	case "vault":
+		keyVersion, sig, err = parseVaultSignature(string(bytes.TrimSpace(data)))
+	case "base64":
+		sig, err = base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data)))
+	case "raw":
+		sig = data
+	}
+	if err != nil {
+		t.logger.Error("Invalid signature", "error", err)
+		return err
+	}
+
+	signer, err := t.newTransitSigner(keyVersion)
+	if err != nil {
+		return err
+	}
+	k := signer.Key
+
+	sigAlg := signer.SigAlg
+	if opts.SigAlg != "" {
+		sigAlg = opts.SigAlg
+	}
+
+	digest, err := hashFile(file, opts.HashAlg)
+	if err != nil {
+		t.logger.Error("Error hashing file", "error", err)
+		return err
+	}
+
+	k.SetSigKeyVersion(signer.Version)
+	valid, err := k.Verify(digest, base64.StdEncoding.EncodeToString(sig), sigAlg, opts.HashAlg, "asn1", true)
+	if err != nil {
+		t.logger.Error("Error verifying with transit", "error", err)
+		return err
+	}
+	if !valid {
+		return fmt.Errorf("invalid signature for version %d of transit key '%s'", signer.Version, k.Name)
+	}
+
+	t.logger.Info("Signature valid", "version", signer.Version, "hash", opts.HashAlg)
+
+	return nil
+}
+
+// hashFile returns the digest of file (or stdin) with the transit hash_algorithm hashAlg
+func hashFile(file string, hashAlg string) ([]byte, error) {
+
+	hash, err := key.VaultHashToCryptoHash(hashAlg)
+	if err != nil {
+		return nil, err
+	}
+	if !hash.Available() {
+		return nil, fmt.Errorf("hash algorithm '%s' not available", hashAlg)
+	}
+
+	var r io.Reader = os.Stdin
+	if !isStdin(file) {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	// stream large files
+	h := hash.New()
+	_, err = io.Copy(h, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.Sum(nil), nil
+}
+
+// parseVaultSignature returns the key version and the signature of a
+// 'vault:v<version>:<base64 signature>' transit signature
+func parseVaultSignature(sigVault string) (int, []byte, error) {
+
+	parts := strings.Split(sigVault, ":")
+	if len(parts) != 3 || parts[0] != "vault" || !strings.HasPrefix(parts[1], "v") {
+		return 0, nil, fmt.Errorf("invalid signature, expecting 'vault:v<version>:<signature>'")
+	}
+
+	version, err := strconv.Atoi(strings.TrimPrefix(parts[1], "v"))
+	if err != nil || version < 1 {
+		return 0, nil, fmt.Errorf("invalid signature key version '%s'", parts[1])
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return version, sig, nil
+}
+
+// checkSignatureFormat returns an error if format is not one of SignatureFormats
+func checkSignatureFormat(format string) error {
+
+	for _, f := range SignatureFormats {
+		if f == format {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unsupported signature format '%s', must be one of %s", format, strings.Join(SignatureFormats, ", "))
+}
@@ -0,0 +1,165 @@
+package transit
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/cloudflare/cfssl/config"
+	"github.com/cloudflare/cfssl/helpers"
+	"github.com/cloudflare/cfssl/log"
+	"github.com/cloudflare/cfssl/signer"
+	"github.com/cloudflare/cfssl/signer/local"
+)
+
+// SignCertOptions are the options of a certificate request signed by a transit CA
+type SignCertOptions struct {
+	// cfssl signing profile name, or default profile if empty
+	Profile string
+	// override the SANs of the CSR if not empty
+	Hosts []string
+}
+
+// LoadSigningPolicy returns the signing policy of the cfssl config file
+func LoadSigningPolicy(configFile string) (*config.Signing, error) {
+
+	cfg, err := config.LoadFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cfg.Valid() {
+		return nil, fmt.Errorf("invalid signing policy in '%s'", configFile)
+	}
+
+	return cfg.Signing, nil
+}
+
+// SigningPolicy returns a signing policy with a default profile built from opts.
+//
+// If nameWhitelist is not empty, the common name and SANs of issued certificates
+// must match the nameWhitelist regexp.
+func SigningPolicy(opts CertificateOptions, nameWhitelist string) (*config.Signing, error) {
+
+	// fail early on unknown usages, cfssl silently ignores them
+	_, _, err := parseUsages(opts.KeyUsage, opts.ExtKeyUsage)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &config.SigningProfile{
+		Usage:        append(append([]string{}, opts.KeyUsage...), opts.ExtKeyUsage...),
+		Expiry:       opts.Expiry,
+		ExpiryString: opts.Expiry.String(),
+		CAConstraint: config.CAConstraint{
+			IsCA:           opts.IsCA,
+			MaxPathLen:     opts.MaxPathLen,
+			MaxPathLenZero: opts.MaxPathLen == 0,
+		},
+	}
+
+	if nameWhitelist != "" {
+		profile.NameWhitelistString = nameWhitelist
+		profile.NameWhitelist, err = regexp.Compile(nameWhitelist)
+		if err != nil {
+			return nil, fmt.Errorf("invalid name whitelist: %w", err)
+		}
+	}
+
+	policy := &config.Signing{
+		Profiles: map[string]*config.SigningProfile{},
+		Default:  profile,
+	}
+
+	if !policy.Valid() {
+		return nil, fmt.Errorf("invalid signing policy")
+	}
+
+	return policy, nil
+}
+
+// SignCertificate issues a certificate for the PEM CSR csrFile according to the
+// signing policy, signed by the CA certificate caCertFile whose private key is
+// version keyVersion (or latest if 0) of the transit key, and writes it PEM
+// encoded to outFile (or stdout)
+func (t *TransitClient) SignCertificate(csrFile, caCertFile string, keyVersion int, policy *config.Signing, opts SignCertOptions, outFile string) error {
+
+	csrPEM, err := os.ReadFile(csrFile)
+	if err != nil {
+		t.logger.Error("Error reading CSR", "error", err)
+		return err
+	}
+
+	// quite cfssl logger
+	log.Level = log.LevelCritical
+
+	caCert, transitSigner, err := t.loadCASigner(caCertFile, keyVersion)
+	if err != nil {
+		return err
+	}
+
+	// use cfssl local signer with the transit signer as CA private key
+	s, err := local.NewSigner(transitSigner, caCert, signer.DefaultSigAlgo(transitSigner), policy)
+	if err != nil {
+		return err
+	}
+
+	// cfssl only enforces the CA path length for CSRs requesting a CA
+	profile, err := signer.Profile(s, opts.Profile)
+	if err != nil {
+		return err
+	}
+
+	err = checkCAConstraint(caCert, profile.CAConstraint)
+	if err != nil {
+		t.logger.Error("Error validating signing profile", "error", err)
+		return err
+	}
+
+	req := signer.SignRequest{
+		Request: string(csrPEM),
+		Profile: opts.Profile,
+	}
+	// cfssl overrides the SANs with any non nil hosts
+	if len(opts.Hosts) > 0 {
+		req.Hosts = opts.Hosts
+	}
+
+	certPEM, err := s.Sign(req)
+	if err != nil {
+		return err
+	}
+
+	cert, err := helpers.ParseCertificatePEM(certPEM)
+	if err != nil {
+		return err
+	}
+
+	t.logger.Info("PEM encoded certificate", "subject", cert.Subject.String(), "issuer", cert.Issuer.String(), "serial", cert.SerialNumber.String(), "not_after", cert.NotAfter.Format(time.RFC3339))
+
+	return writeOutput(outFile, certPEM, 0644)
+}
+
+// checkCAConstraint returns an error if caCert path length does not allow
+// issuing a CA certificate with constraint c
+func checkCAConstraint(caCert *x509.Certificate, c config.CAConstraint) error {
+
+	if !c.IsCA {
+		return nil
+	}
+
+	if caCert.MaxPathLen == 0 && caCert.MaxPathLenZero {
+		return fmt.Errorf("CA '%s' cannot issue CA certificates (max path length 0)", caCert.Subject.String())
+	}
+
+	if caCert.MaxPathLen > 0 {
+		unlimited := c.MaxPathLen < 0 || (c.MaxPathLen == 0 && !c.MaxPathLenZero)
+		if unlimited || c.MaxPathLen >= caCert.MaxPathLen {
+			return fmt.Errorf("max path length must be lower than %d of CA '%s'", caCert.MaxPathLen, caCert.Subject.String())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,249 @@
+package transit
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cfssl/config"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/internal/transittest"
+)
+
+// newTestCSR returns the PEM file of a CSR for commonName of a new local key
+func newTestCSR(t *testing.T, commonName string, dnsNames ...string) string {
+	t.Helper()
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: commonName},
+		DNSNames: dnsNames,
+	}, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return writeTestFile(t, "csr.pem", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})))
+}
+
+func TestCheckCAConstraint(t *testing.T) {
+
+	// CA path length as parsed by x509
+	unlimited := &x509.Certificate{MaxPathLen: -1}
+	zero := &x509.Certificate{MaxPathLen: 0, MaxPathLenZero: true}
+	two := &x509.Certificate{MaxPathLen: 2}
+
+	tests := []struct {
+		name    string
+		caCert  *x509.Certificate
+		c       config.CAConstraint
+		wantErr bool
+	}{
+		{name: "leaf from path length 0", caCert: zero, c: config.CAConstraint{}},
+		{name: "CA from unlimited", caCert: unlimited, c: config.CAConstraint{IsCA: true, MaxPathLen: -1}},
+		{name: "CA from path length 0", caCert: zero, c: config.CAConstraint{IsCA: true, MaxPathLen: 0, MaxPathLenZero: true}, wantErr: true},
+		{name: "CA path length 1 from 2", caCert: two, c: config.CAConstraint{IsCA: true, MaxPathLen: 1}},
+		{name: "CA path length 0 from 2", caCert: two, c: config.CAConstraint{IsCA: true, MaxPathLen: 0, MaxPathLenZero: true}},
+		{name: "CA path length 2 from 2", caCert: two, c: config.CAConstraint{IsCA: true, MaxPathLen: 2}, wantErr: true},
+		{name: "CA unlimited from 2", caCert: two, c: config.CAConstraint{IsCA: true, MaxPathLen: -1}, wantErr: true},
+		// cfssl unset path length
+		{name: "CA unset from 2", caCert: two, c: config.CAConstraint{IsCA: true}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+
+			err := checkCAConstraint(tc.caCert, tc.c)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("checkCAConstraint error = %v, want error %t", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestSigningPolicy(t *testing.T) {
+
+	policy, err := SigningPolicy(CertificateOptions{
+		Expiry:      48 * time.Hour,
+		KeyUsage:    []string{"signing", "key encipherment"},
+		ExtKeyUsage: []string{"server auth"},
+		IsCA:        true,
+		MaxPathLen:  0,
+	}, `^.*\.example\.com$`)
+	if err != nil {
+		t.Fatalf("SigningPolicy: %v", err)
+	}
+
+	p := policy.Default
+	if strings.Join(p.Usage, ",") != "signing,key encipherment,server auth" {
+		t.Errorf("usage = %v", p.Usage)
+	}
+	if p.Expiry != 48*time.Hour {
+		t.Errorf("expiry = %s, want 48h", p.Expiry)
+	}
+	if !p.CAConstraint.IsCA || p.CAConstraint.MaxPathLen != 0 || !p.CAConstraint.MaxPathLenZero {
+		t.Errorf("CA constraint = %+v, want CA with path length 0", p.CAConstraint)
+	}
+	if p.NameWhitelist == nil || !p.NameWhitelist.MatchString("www.example.com") {
+		t.Error("name whitelist not compiled")
+	}
+
+	_, err = SigningPolicy(CertificateOptions{Expiry: time.Hour, KeyUsage: []string{"sign everything"}}, "")
+	if err == nil {
+		t.Error("SigningPolicy with an unknown usage succeeded")
+	}
+	_, err = SigningPolicy(CertificateOptions{Expiry: time.Hour}, "(")
+	if err == nil {
+		t.Error("SigningPolicy with an invalid name whitelist succeeded")
+	}
+}
+
+func TestLoadSigningPolicy(t *testing.T) {
+
+	file := writeTestFile(t, "config.json", `{
+  "signing": {
+    "default": {"usages": ["signing", "server auth"], "expiry": "24h"},
+    "profiles": {
+      "intermediate": {"usages": ["cert sign", "crl sign"], "expiry": "8760h", "ca_constraint": {"is_ca": true, "max_path_len": 0, "max_path_len_zero": true}}
+    }
+  }
+}`)
+
+	policy, err := LoadSigningPolicy(file)
+	if err != nil {
+		t.Fatalf("LoadSigningPolicy: %v", err)
+	}
+	p, ok := policy.Profiles["intermediate"]
+	if !ok || !p.CAConstraint.IsCA || p.Expiry != 8760*time.Hour {
+		t.Errorf("intermediate profile = %+v", p)
+	}
+
+	_, err = LoadSigningPolicy(writeTestFile(t, "invalid.json", `{"signing": {"default": {"expiry": "24h"}, "profiles": {"server": {"expiry": "24h"}}}}`))
+	if err == nil {
+		t.Error("LoadSigningPolicy of a profile without usages succeeded")
+	}
+}
+
+func TestSignCertificate(t *testing.T) {
+
+	for _, keyType := range []string{"rsa-2048", "ecdsa-p256"} {
+		t.Run(keyType, func(t *testing.T) {
+
+			k := transittest.GenerateKey(t, keyType, 2)
+			tc := newTestTransitClient(t, "ca", k)
+			caFile, caCert := newTestCA(t, k, 2)
+			csrFile := newTestCSR(t, "www.example.com", "www.example.com")
+
+			policy, err := SigningPolicy(CertificateOptions{
+				Expiry:      24 * time.Hour,
+				KeyUsage:    []string{"signing", "key encipherment"},
+				ExtKeyUsage: []string{"server auth"},
+				MaxPathLen:  -1,
+			}, `^.*\.example\.com$`)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			out := filepath.Join(t.TempDir(), "cert.pem")
+			err = tc.SignCertificate(csrFile, caFile, 2, policy, SignCertOptions{Hosts: []string{"www.example.com", "api.example.com"}}, out)
+			if err != nil {
+				t.Fatalf("SignCertificate: %v", err)
+			}
+			if k.LastSignVersion != 2 {
+				t.Errorf("signed with version %d, want 2", k.LastSignVersion)
+			}
+
+			cert := readTestCertificate(t, out)
+
+			roots := x509.NewCertPool()
+			roots.AddCert(caCert)
+			for _, name := range []string{"www.example.com", "api.example.com"} {
+				_, err = cert.Verify(x509.VerifyOptions{
+					DNSName:   name,
+					Roots:     roots,
+					KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+				})
+				if err != nil {
+					t.Errorf("Verify %s: %v", name, err)
+				}
+			}
+
+			if cert.IsCA {
+				t.Error("leaf certificate is a CA")
+			}
+			if cert.KeyUsage != x509.KeyUsageDigitalSignature|x509.KeyUsageKeyEncipherment {
+				t.Errorf("key usage = %d", cert.KeyUsage)
+			}
+			if validity := cert.NotAfter.Sub(cert.NotBefore); validity < 24*time.Hour || validity > 25*time.Hour {
+				t.Errorf("validity = %s, want 24h", validity)
+			}
+
+			// name whitelist
+			err = tc.SignCertificate(newTestCSR(t, "www.example.org", "www.example.org"), caFile, 2, policy, SignCertOptions{}, out)
+			if err == nil {
+				t.Error("SignCertificate of a name outside the whitelist succeeded")
+			}
+		})
+	}
+}
+
+func TestSignCertificateCAPathLength(t *testing.T) {
+
+	k := transittest.GenerateKey(t, "ecdsa-p256", 1)
+	tc := newTestTransitClient(t, "ca", k)
+
+	// CA with path length 0
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, k.Versions[0].Public(), k.Versions[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	caFile := writeTestFile(t, "ca.pem", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})))
+
+	policy, err := LoadSigningPolicy(writeTestFile(t, "config.json", `{
+  "signing": {
+    "default": {"usages": ["signing"], "expiry": "24h"},
+    "profiles": {
+      "intermediate": {"usages": ["cert sign"], "expiry": "24h", "ca_constraint": {"is_ca": true, "max_path_len": 0, "max_path_len_zero": true}}
+    }
+  }
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(t.TempDir(), "cert.pem")
+	err = tc.SignCertificate(newTestCSR(t, "Intermediate CA"), caFile, 0, policy, SignCertOptions{Profile: "intermediate"}, out)
+	if err == nil || !strings.Contains(err.Error(), "cannot issue CA certificates") {
+		t.Fatalf("SignCertificate error = %v, want path length error", err)
+	}
+	if k.SignCount != 0 {
+		t.Error("signed with transit despite the CA path length")
+	}
+
+	// default profile issues leaf certificates
+	err = tc.SignCertificate(newTestCSR(t, "leaf"), caFile, 0, policy, SignCertOptions{}, out)
+	if err != nil {
+		t.Fatalf("SignCertificate: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package transit
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cloudflare/cfssl/helpers"
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/key"
+)
+
+// newTransitSigner returns a crypto.Signer for version keyVersion
+// (or latest if 0) of the transit key
+func (t *TransitClient) newTransitSigner(keyVersion int) (*key.TransitSigner, error) {
+
+	// get latest key info
+	// version public keys
+	k, err := t.syncTransitKey()
+	if err != nil {
+		return nil, err
+	}
+
+	// set default signing alg pkcs1v15 for RSA
+	vaultSigAlg := "pss"
+	if strings.HasPrefix(k.Type, "rsa-") {
+		vaultSigAlg = "pkcs1v15"
+	}
+
+	// create new Transit SIgner, validates key version
+	signer, err := key.NewTransitSignerForVersion(k, keyVersion, vaultSigAlg)
+	if err != nil {
+		t.logger.Error("Invalid transit key version", "error", err)
+		return nil, err
+	}
+
+	return signer, nil
+}
+
+// loadCASigner returns the PEM CA certificate caCertFile and the signer for
+// version keyVersion (or latest if 0) of the transit key, the CA certificate
+// must be issued for that transit key
+func (t *TransitClient) loadCASigner(caCertFile string, keyVersion int) (*x509.Certificate, *key.TransitSigner, error) {
+
+	caPEM, err := os.ReadFile(caCertFile)
+	if err != nil {
+		t.logger.Error("Error reading CA certificate", "error", err)
+		return nil, nil, err
+	}
+
+	caCert, err := helpers.ParseCertificatePEM(caPEM)
+	if err != nil {
+		t.logger.Error("Error parsing CA certificate", "error", err)
+		return nil, nil, err
+	}
+
+	if !caCert.IsCA {
+		return nil, nil, fmt.Errorf("certificate '%s' is not a CA", caCert.Subject.String())
+	}
+
+	transitSigner, err := t.newTransitSigner(keyVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = checkCertificateMatchesKey(caCert, transitSigner)
+	if err != nil {
+		t.logger.Error("Error validating CA certificate", "error", err)
+		return nil, nil, err
+	}
+
+	return caCert, transitSigner, nil
+}
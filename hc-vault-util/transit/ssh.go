@@ -0,0 +1,163 @@
+package transit
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// default extensions of user certificates, as 'ssh-keygen'
+var defaultSSHUserExtensions = map[string]string{
+	"permit-X11-forwarding":   "",
+	"permit-agent-forwarding": "",
+	"permit-port-forwarding":  "",
+	"permit-pty":              "",
+	"permit-user-rc":          "",
+}
+
+// SSHCertOptions are the options of an SSH certificate signed with a transit key
+type SSHCertOptions struct {
+	// 'user' or 'host'
+	CertType string
+	// certificate key ID, logged by sshd
+	KeyID string
+	// user names or host names, at least one unless AllowAnyPrincipal
+	Principals []string
+	// allow a certificate without principals, valid for any user or host
+	AllowAnyPrincipal bool
+	// validity period from now
+	Validity time.Duration
+	// extensions, or default user extensions if nil
+	Extensions map[string]string
+	// critical options (e.g. 'force-command', 'source-address')
+	CriticalOptions map[string]string
+}
+
+// SSHSignCert issues the SSH certificate of the public key pubKeyFile
+// (authorized_keys format) signed with version keyVersion (or latest if 0) of the
+// transit key as CA, and writes it to outFile (or stdout)
+func (t *TransitClient) SSHSignCert(pubKeyFile string, keyVersion int, opts SSHCertOptions, outFile string) error {
+
+	data, err := os.ReadFile(pubKeyFile)
+	if err != nil {
+		t.logger.Error("Error reading public key", "error", err)
+		return err
+	}
+
+	pub, comment, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		t.logger.Error("Error parsing public key", "error", err)
+		return err
+	}
+
+	signer, err := t.newSSHSigner(keyVersion)
+	if err != nil {
+		return err
+	}
+
+	cert, err := CreateSSHCertificate(pub, signer, opts)
+	if err != nil {
+		return err
+	}
+
+	t.logger.Info("SSH certificate", "type", opts.CertType, "key_id", cert.KeyId, "serial", cert.Serial, "principals", strings.Join(cert.ValidPrincipals, ","), "valid_before", time.Unix(int64(cert.ValidBefore), 0).UTC().Format(time.RFC3339), "ca", ssh.FingerprintSHA256(signer.PublicKey()))
+
+	out := bytes.TrimSpace(ssh.MarshalAuthorizedKey(cert))
+	if comment != "" {
+		out = append(out, ' ')
+		out = append(out, comment...)
+	}
+
+	return writeOutput(outFile, append(out, '\n'), 0644)
+}
+
+// SSHPublicKey writes the SSH CA public key of version keyVersion (or latest if 0)
+// of the transit key in authorized_keys format to outFile (or stdout)
+func (t *TransitClient) SSHPublicKey(keyVersion int, outFile string) error {
+
+	signer, err := t.newSSHSigner(keyVersion)
+	if err != nil {
+		return err
+	}
+
+	t.logger.Info("SSH CA public key", "ssh_sha256", ssh.FingerprintSHA256(signer.PublicKey()))
+
+	return writeOutput(outFile, ssh.MarshalAuthorizedKey(signer.PublicKey()), 0644)
+}
+
+// CreateSSHCertificate returns the SSH certificate of pub signed by the CA signer
+func CreateSSHCertificate(pub ssh.PublicKey, signer ssh.Signer, opts SSHCertOptions) (*ssh.Certificate, error) {
+
+	var certType uint32
+	switch opts.CertType {
+	case "user":
+		certType = ssh.UserCert
+	case "host":
+		certType = ssh.HostCert
+	default:
+		return nil, fmt.Errorf("invalid certificate type '%s', must be one of user, host", opts.CertType)
+	}
+
+	// sshd accepts a certificate without principals for any user or host
+	if len(opts.Principals) == 0 && !opts.AllowAnyPrincipal {
+		return nil, fmt.Errorf("at least one principal is required, a certificate without principals is valid for any %s", opts.CertType)
+	}
+
+	extensions := opts.Extensions
+	if extensions == nil && certType == ssh.UserCert {
+		extensions = defaultSSHUserExtensions
+	}
+
+	serial := make([]byte, 8)
+	_, err := rand.Read(serial)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	cert := &ssh.Certificate{
+		Key:             pub,
+		Serial:          binary.BigEndian.Uint64(serial),
+		CertType:        certType,
+		KeyId:           opts.KeyID,
+		ValidPrincipals: opts.Principals,
+		ValidAfter:      uint64(now.Add(-certificateBackdate).Unix()),
+		ValidBefore:     uint64(now.Add(opts.Validity).Unix()),
+		Permissions: ssh.Permissions{
+			CriticalOptions: opts.CriticalOptions,
+			Extensions:      extensions,
+		},
+	}
+
+	// RSA CA keys sign with rsa-sha2-512
+	err = cert.SignCert(rand.Reader, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	return cert, nil
+}
+
+// newSSHSigner returns an ssh.Signer for version keyVersion (or latest if 0)
+// of the transit key
+func (t *TransitClient) newSSHSigner(keyVersion int) (ssh.Signer, error) {
+
+	transitSigner, err := t.newTransitSigner(keyVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := ssh.NewSignerFromSigner(transitSigner)
+	if err != nil {
+		t.logger.Error("Error creating SSH signer", "error", err)
+		return nil, err
+	}
+
+	return signer, nil
+}
@@ -0,0 +1,70 @@
+package transit
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestCreateSSHCertificatePrincipals(t *testing.T) {
+
+	_, caPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caSigner, err := ssh.NewSignerFromKey(caPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := ssh.NewPublicKey(userPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := SSHCertOptions{CertType: "user", KeyID: "alice", Validity: time.Hour}
+
+	_, err = CreateSSHCertificate(pub, caSigner, opts)
+	if err == nil {
+		t.Error("CreateSSHCertificate without principals succeeded, want error")
+	}
+
+	opts.AllowAnyPrincipal = true
+	cert, err := CreateSSHCertificate(pub, caSigner, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cert.ValidPrincipals) != 0 {
+		t.Errorf("principals = %v, want none", cert.ValidPrincipals)
+	}
+
+	opts.AllowAnyPrincipal = false
+	opts.Principals = []string{"alice", "admin"}
+	cert, err = CreateSSHCertificate(pub, caSigner, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checker := &ssh.CertChecker{
+		IsUserAuthority: func(auth ssh.PublicKey) bool {
+			return string(auth.Marshal()) == string(caSigner.PublicKey().Marshal())
+		},
+	}
+	for _, principal := range opts.Principals {
+		err = checker.CheckCert(principal, cert)
+		if err != nil {
+			t.Errorf("CheckCert(%s): %v", principal, err)
+		}
+	}
+	err = checker.CheckCert("root", cert)
+	if err == nil {
+		t.Error("CheckCert(root) succeeded, want error")
+	}
+}
@@ -0,0 +1,99 @@
+package transit
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+)
+
+const (
+	// transit hmac key size bounds in bytes
+	hmacMinKeySize = 32
+	hmacMaxKeySize = 512
+)
+
+var (
+	// expected key size in bytes for symmetric key types
+	symmetricKeySizes = map[string]int{
+		"aes128-gcm96":      16,
+		"aes256-gcm96":      32,
+		"chacha20-poly1305": 32,
+	}
+)
+
+// ImportSymmetricKey imports the symmetric key material from keyFile into transit as keyType.
+//
+// keyType is one of aes128-gcm96, aes256-gcm96, chacha20-poly1305 or hmac, and
+// encoding one of raw, hex or base64.
+func (t *TransitClient) ImportSymmetricKey(keyFile, keyType, encoding string) error {
+
+	keyMaterial, err := t.loadSymmetricKey(keyFile, keyType, encoding)
+	if err != nil {
+		return err
+	}
+
+	return t.importKeyMaterial(keyMaterial, keyType)
+}
+
+// loadSymmetricKey reads and validates the symmetric key material from keyFile
+func (t *TransitClient) loadSymmetricKey(keyFile, keyType, encoding string) ([]byte, error) {
+	// read key file
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		t.logger.Error("Error reading symmetric key", "error", err)
+		return nil, err
+	}
+
+	keyMaterial, err := decodeKeyMaterial(data, encoding)
+	if err != nil {
+		t.logger.Error("Error decoding symmetric key", "encoding", encoding, "error", err)
+		return nil, err
+	}
+
+	err = validateSymmetricKey(keyMaterial, keyType)
+	if err != nil {
+		t.logger.Error("Invalid symmetric key", "error", err)
+		return nil, err
+	}
+
+	return keyMaterial, nil
+}
+
+// decodeKeyMaterial returns the raw key bytes from data
+func decodeKeyMaterial(data []byte, encoding string) ([]byte, error) {
+
+	switch encoding {
+	case "raw":
+		return data, nil
+	case "hex":
+		return hex.DecodeString(string(bytes.TrimSpace(data)))
+	case "base64":
+		return base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data)))
+	default:
+		return nil, fmt.Errorf("unsupported key encoding '%s', must be one of raw, hex, base64", encoding)
+	}
+}
+
+// validateSymmetricKey returns an error if the size of keyMaterial does not match keyType
+func validateSymmetricKey(keyMaterial []byte, keyType string) error {
+
+	if keyType == "hmac" {
+		if len(keyMaterial) < hmacMinKeySize || len(keyMaterial) > hmacMaxKeySize {
+			return fmt.Errorf("invalid hmac key size %d bytes, must be between %d and %d bytes", len(keyMaterial), hmacMinKeySize, hmacMaxKeySize)
+		}
+		return nil
+	}
+
+	size, ok := symmetricKeySizes[keyType]
+	if !ok {
+		return fmt.Errorf("unsupported symmetric key type '%s', must be one of aes128-gcm96, aes256-gcm96, chacha20-poly1305, hmac", keyType)
+	}
+
+	if len(keyMaterial) != size {
+		return fmt.Errorf("invalid %s key size %d bytes, must be %d bytes", keyType, len(keyMaterial), size)
+	}
+
+	return nil
+}
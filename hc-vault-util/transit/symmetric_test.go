@@ -0,0 +1,64 @@
+package transit
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/internal/transittest"
+)
+
+func TestImportSymmetricKeyNewVersion(t *testing.T) {
+
+	for _, keyType := range []string{"aes128-gcm96", "aes256-gcm96", "chacha20-poly1305", "hmac"} {
+		t.Run(keyType, func(t *testing.T) {
+
+			size := symmetricKeySizes[keyType]
+			if keyType == "hmac" {
+				size = hmacMinKeySize
+			}
+			keyMaterial := make([]byte, size)
+			_, err := rand.Read(keyMaterial)
+			if err != nil {
+				t.Fatal(err)
+			}
+			keyFile := writeTestFile(t, "key.hex", hex.EncodeToString(keyMaterial))
+
+			existing := transittest.NewSymmetricKey(keyType, 1)
+			tc := newTestTransitClient(t, "sym", existing)
+			tc.SetImportOptions(ImportOptions{NewVersion: true})
+
+			// as a manifest entry
+			err = tc.Import(ImportSource{SymmetricKey: keyFile, KeyType: keyType, KeyEncoding: "hex"})
+			if err != nil {
+				t.Fatalf("Import: %v", err)
+			}
+
+			if len(existing.Versions) != 2 || len(existing.Imported) != 1 {
+				t.Fatalf("transit key has %d versions and %d imports, want 2 and 1", len(existing.Versions), len(existing.Imported))
+			}
+			if !bytes.Equal(existing.Imported[0], keyMaterial) {
+				t.Error("imported key material differs from the key file")
+			}
+		})
+	}
+}
+
+func TestImportSymmetricKeyNewVersionTypeMismatch(t *testing.T) {
+
+	keyFile := writeTestFile(t, "key.hex", hex.EncodeToString(make([]byte, 32)))
+
+	existing := transittest.NewSymmetricKey("aes128-gcm96", 1)
+	tc := newTestTransitClient(t, "sym", existing)
+	tc.SetImportOptions(ImportOptions{NewVersion: true})
+
+	err := tc.ImportSymmetricKey(keyFile, "aes256-gcm96", "hex")
+	if err == nil || !strings.Contains(err.Error(), "key type mismatch") {
+		t.Fatalf("ImportSymmetricKey error = %v, want key type mismatch", err)
+	}
+	if len(existing.Imported) != 0 {
+		t.Error("key imported despite the type mismatch")
+	}
+}
@@ -0,0 +1,117 @@
+package transit
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/key"
+)
+
+// verifyImportedKey checks that the latest version of the transit key is privKey:
+// the transit public key must match the local public key, and a random challenge
+// signed by transit must verify locally
+func (t *TransitClient) verifyImportedKey(privKey crypto.PrivateKey) error {
+
+	signer, ok := privKey.(crypto.Signer)
+	if !ok {
+		return fmt.Errorf("unsupported private key type %T", privKey)
+	}
+	localPub, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok {
+		return fmt.Errorf("unsupported public key type %T", signer.Public())
+	}
+
+	k, err := t.syncTransitKey()
+	if err != nil {
+		return err
+	}
+
+	// public key of the latest (imported) version
+	var transitPub crypto.PublicKey
+	for _, p := range k.PublicKeys {
+		if p.Version == k.Version {
+			transitPub = p.PublicKey
+			break
+		}
+	}
+
+	if transitPub == nil {
+		return fmt.Errorf("public key not found for version %d of %s/keys/%s", k.Version, k.MountPath, k.Name)
+	}
+
+	if !localPub.Equal(transitPub) {
+		return fmt.Errorf("public key of version %d of %s/keys/%s does not match the imported private key", k.Version, k.MountPath, k.Name)
+	}
+
+	t.logger.Debug("public key matches", "version", k.Version)
+
+	// random challenge to sign with transit
+	challenge := make([]byte, 32)
+	_, err = rand.Read(challenge)
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(challenge)
+
+	switch pub := transitPub.(type) {
+	case *rsa.PublicKey:
+		sig, err := signChallenge(k, digest[:], "pkcs1v15", true)
+		if err != nil {
+			return err
+		}
+		err = rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig)
+		if err != nil {
+			return fmt.Errorf("invalid transit signature: %w", err)
+		}
+
+	case *ecdsa.PublicKey:
+		sig, err := signChallenge(k, digest[:], "", true)
+		if err != nil {
+			return err
+		}
+		if !ecdsa.VerifyASN1(pub, digest[:], sig) {
+			return fmt.Errorf("invalid transit signature")
+		}
+
+	case ed25519.PublicKey:
+		// ed25519 does not support prehashed input
+		sig, err := signChallenge(k, challenge, "", false)
+		if err != nil {
+			return err
+		}
+		if !ed25519.Verify(pub, challenge, sig) {
+			return fmt.Errorf("invalid transit signature")
+		}
+
+	default:
+		return fmt.Errorf("unsupported public key type %T", transitPub)
+	}
+
+	t.logger.Info("Imported key verified", "path", fmt.Sprintf("%s/keys/%s", k.MountPath, k.Name), "version", k.Version)
+	return nil
+}
+
+// signChallenge signs input with transit (sha2-256) and returns the decoded signature
+func signChallenge(k *key.VaultTransitKey, input []byte, sigAlg string, prehashed bool) ([]byte, error) {
+
+	sigVault, err := k.Sign(input, sigAlg, "sha2-256", "asn1", prehashed)
+	if err != nil {
+		return nil, err
+	}
+
+	// Vault transit signature are prefixed with
+	// 'vault:vX:' indicating the version of key used for this signature
+	sigParts := strings.Split(sigVault, ":")
+	if len(sigParts) != 3 || !strings.HasPrefix(sigVault, "vault:v") {
+		return nil, fmt.Errorf("invalid signature expecting prefix 'vault:v' but got %s", sigVault)
+	}
+
+	return base64.StdEncoding.DecodeString(sigParts[2])
+}
@@ -0,0 +1,86 @@
+package transit
+
+import (
+	"crypto"
+	"strings"
+	"testing"
+
+	"github.com/vdbulcke/hc-vault-util/hc-vault-util/transit/internal/transittest"
+)
+
+func newTestSigners(t *testing.T) map[string]crypto.Signer {
+	t.Helper()
+
+	signers := map[string]crypto.Signer{}
+	for _, keyType := range []string{"rsa-2048", "ecdsa-p256", "ed25519"} {
+		signers[keyType] = transittest.GenerateSigner(t, keyType)
+	}
+
+	return signers
+}
+
+func TestVerifyImportedKey(t *testing.T) {
+
+	signers := newTestSigners(t)
+	others := newTestSigners(t)
+
+	for keyType, priv := range signers {
+		t.Run(keyType, func(t *testing.T) {
+
+			tc := newTestTransitClient(t, "imported", transittest.NewKey(keyType, priv))
+			err := tc.verifyImportedKey(priv)
+			if err != nil {
+				t.Fatalf("verifyImportedKey: %v", err)
+			}
+
+			// transit key is another key
+			tc = newTestTransitClient(t, "imported", transittest.NewKey(keyType, others[keyType]))
+			err = tc.verifyImportedKey(priv)
+			if err == nil || !strings.Contains(err.Error(), "does not match") {
+				t.Errorf("verifyImportedKey of another key: error = %v, want public key mismatch", err)
+			}
+
+			// transit signs with another key than its public key
+			invalid := transittest.NewKey(keyType, priv)
+			invalid.Signer = others[keyType]
+			tc = newTestTransitClient(t, "imported", invalid)
+			err = tc.verifyImportedKey(priv)
+			if err == nil || !strings.Contains(err.Error(), "invalid transit signature") {
+				t.Errorf("verifyImportedKey of invalid signature: error = %v, want invalid transit signature", err)
+			}
+		})
+	}
+}
+
+func TestImportVerifyRequiresPrivateKey(t *testing.T) {
+
+	opts := ImportOptions{Verify: true}
+
+	for _, src := range []ImportSource{
+		{SymmetricKey: "aes.key", KeyType: "aes256-gcm96"},
+		{CiphertextFile: "key.wrapped", KeyType: "rsa-2048"},
+	} {
+		err := src.ValidateOptions(opts)
+		if err == nil {
+			t.Errorf("ValidateOptions(%+v) with verify succeeded, want error", src)
+		}
+	}
+
+	for _, src := range []ImportSource{{KeyFile: "key.pem"}, {PKCS12: "bundle.p12"}} {
+		err := src.ValidateOptions(opts)
+		if err != nil {
+			t.Errorf("ValidateOptions(%+v) with verify: %v", src, err)
+		}
+	}
+
+	_, err := LoadKeyManifest(writeTestFile(t, "keys.yaml", `
+keys:
+  - name: aes-key
+    symmetric_key: ./aes.hex
+    key_type: aes256-gcm96
+    verify: true
+`))
+	if err == nil {
+		t.Error("LoadKeyManifest of symmetric key with verify succeeded, want error")
+	}
+}
@@ -0,0 +1,226 @@
+package transit
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+
+	"github.com/google/tink/go/kwp/subtle"
+)
+
+// SetWrappingKey sets the RSA wrapping key used to wrap keys, instead of
+// reading it from the transit mount (e.g. for offline wrapping)
+func (t *TransitClient) SetWrappingKey(wrappingKey *rsa.PublicKey) {
+
+	t.wrappingKey = wrappingKey
+}
+
+// LoadWrappingKey reads the PEM encoded RSA wrapping key from file
+func LoadWrappingKey(file string) (*rsa.PublicKey, error) {
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseWrappingKey(data)
+}
+
+// ExportWrappingKey writes the PEM encoded wrapping key of the transit mount to outFile (or stdout)
+func (t *TransitClient) ExportWrappingKey(outFile string) error {
+
+	wrappingKey, err := t.getWrappingKey()
+	if err != nil {
+		t.logger.Error("error reading wrapping key", "error", err)
+		return err
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(wrappingKey)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(outFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644)
+}
+
+// WrapPrivateKey wraps the PEM private key from keyFile offline, writes the
+// base64 ciphertext to outFile (or stdout), and returns the transit key type
+func (t *TransitClient) WrapPrivateKey(keyFile string, passphrase *PassphraseSource, outFile string) (string, error) {
+
+	privKey, err := t.loadPrivateKey(keyFile, passphrase)
+	if err != nil {
+		return "", err
+	}
+
+	pkcs8Key, keyType, err := t.privateKeyMaterial(privKey)
+	if err != nil {
+		return "", err
+	}
+
+	return keyType, t.wrapToFile(pkcs8Key, keyType, outFile)
+}
+
+// WrapSymmetricKey wraps the symmetric key material from keyFile offline, and writes the
+// base64 ciphertext to outFile (or stdout)
+func (t *TransitClient) WrapSymmetricKey(keyFile, keyType, encoding, outFile string) error {
+
+	keyMaterial, err := t.loadSymmetricKey(keyFile, keyType, encoding)
+	if err != nil {
+		return err
+	}
+
+	return t.wrapToFile(keyMaterial, keyType, outFile)
+}
+
+// wrapToFile wraps the target key and writes the base64 ciphertext to outFile
+func (t *TransitClient) wrapToFile(targetKey []byte, keyType, outFile string) error {
+
+	err := t.importOpts.Validate(keyType)
+	if err != nil {
+		t.logger.Error("Invalid import options", "error", err)
+		return err
+	}
+
+	base64Ciphertext, err := t.wrapKeyMaterial(targetKey)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(outFile, []byte(base64Ciphertext+"\n"), 0600)
+}
+
+// wrapKeyMaterial wraps the target key with an ephemeral AES key (KWP), itself
+// encrypted with the RSA wrapping key (RSA-OAEP), and returns the base64 ciphertext
+func (t *TransitClient) wrapKeyMaterial(targetKey []byte) (string, error) {
+
+	// implementing steps from doc
+	// https://developer.hashicorp.com/vault/docs/secrets/transit/key-wrapping-guide#software-example-go
+	t.logger.Debug("generating wrapping key from transit...")
+	wrappingKey, err := t.getWrappingKey()
+	if err != nil {
+		t.logger.Error("error generating wrapping key", "error", err)
+		return "", err
+	}
+
+	t.logger.Debug("generating AES ephemeral key...")
+	ephemeralAESKey, err := t.genAESKey()
+	if err != nil {
+		t.logger.Error("error generating AES ephemeral key", "error", err)
+		return "", err
+	}
+
+	t.logger.Debug("generating key wrap from AES key")
+	wrapKWP, err := subtle.NewKWP(ephemeralAESKey)
+	if err != nil {
+		t.logger.Error("error generating key Wrap", "error", err)
+		return "", err
+	}
+
+	t.logger.Debug("Wrapping target key...")
+	wrappedTargetKey, err := wrapKWP.Wrap(targetKey)
+	if err != nil {
+		t.logger.Error("error wrapping target key", "error", err)
+		return "", err
+	}
+
+	//
+	t.logger.Debug("encrypting AES ephemeral key with RSA wrapping key")
+	wrappedAESKey, err := rsa.EncryptOAEP(
+		t.importOpts.oaepHash(),
+		rand.Reader,
+		wrappingKey,
+		ephemeralAESKey,
+		[]byte{},
+	)
+	if err != nil {
+		t.logger.Error("encrypting AES ephemeral key", "error", err)
+		return "", err
+	}
+
+	// combined the payload and base64 encode
+	combinedCiphertext := append(wrappedAESKey, wrappedTargetKey...)
+	return base64.StdEncoding.EncodeToString(combinedCiphertext), nil
+}
+
+func (t *TransitClient) getWrappingKey() (*rsa.PublicKey, error) {
+
+	// offline wrapping key
+	if t.wrappingKey != nil {
+		return t.wrappingKey, nil
+	}
+
+	// wrapping key only read once per mount
+	if wrappingKey, ok := t.wrappingKeys[t.transitMount]; ok {
+		return wrappingKey, nil
+	}
+
+	// transit key api path
+	apiPath := fmt.Sprintf("%s/wrapping_key", t.transitMount)
+	// read transit key
+	keyInfo, err := t.client.Logical().ReadWithContext(t.ctx, apiPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if keyInfo == nil {
+		return nil, fmt.Errorf("no response reading wrapping key %s", apiPath)
+	}
+
+	// parse key type
+	publicKeyPem, ok := keyInfo.Data["public_key"].(string)
+	if !ok {
+		return nil, fmt.Errorf("error parsing wrapping key %s", apiPath)
+	}
+
+	t.logger.Debug("go wrapping key", "public_key", publicKeyPem)
+
+	wrappingKey, err := parseWrappingKey([]byte(publicKeyPem))
+	if err != nil {
+		return nil, fmt.Errorf("%w for %s", err, apiPath)
+	}
+
+	t.wrappingKeys[t.transitMount] = wrappingKey
+	return wrappingKey, nil
+
+}
+
+// parseWrappingKey parses the PEM encoded RSA wrapping key
+func parseWrappingKey(publicKeyPem []byte) (*rsa.PublicKey, error) {
+
+	block, _ := pem.Decode(publicKeyPem)
+
+	if block == nil {
+		return nil, fmt.Errorf("error Pem Decoding pub key")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// cast is as a RSA
+	var wrappingKey *rsa.PublicKey
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+
+		wrappingKey = pub
+
+	default:
+		return nil, fmt.Errorf("unknown type of wrapping key")
+	}
+
+	return wrappingKey, nil
+}
+
+func (t *TransitClient) genAESKey() ([]byte, error) {
+	ephemeralAESKey := make([]byte, 32)
+	_, err := rand.Read(ephemeralAESKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return ephemeralAESKey, nil
+}